@@ -0,0 +1,64 @@
+package daw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// aliasing returns the energy, in decibels relative to the whole wave, a wave function produces
+// above half the nyquist frequency at frequencies which are not harmonics of pitch.
+func aliasing(pitch Pitch, waveFunc func(*PitchReader) float64) float64 {
+	const samples = 32768
+	format := pcm.Format{SampleRate: 44100, Channels: 1, Bits: 32}
+	pr := &PitchReader{
+		Format:   format,
+		Pitch:    &pitch,
+		Volume:   1,
+		WaveFunc: waveFunc,
+	}
+	wave := make([]float64, samples)
+	for i := range wave {
+		wave[i] = pr.Next()
+	}
+	spectrum := Spectrum(wave, BlackmanWindow)
+
+	// the blackman window spreads each frequency across a few bins
+	const tolerance = 4
+	binWidth := BinFrequency(1, samples, format.SampleRate)
+	halfNyquist := float64(format.SampleRate) / 4
+	var total, aliased float64
+	for bin, db := range spectrum {
+		power := math.Pow(10, db/10)
+		total += power
+		freq := float64(bin) * binWidth
+		harmonic := math.Round(freq / float64(pitch))
+		if freq > halfNyquist && math.Abs(freq-harmonic*float64(pitch)) > tolerance*binWidth {
+			aliased += power
+		}
+	}
+	return 10 * math.Log10(aliased/total)
+}
+
+func TestBandLimitedAliasing(t *testing.T) {
+	// how many decibels less aliasing each band limited wave must produce than its naive version
+	const margin = 10
+	waves := []struct {
+		name         string
+		naive, bandl func(*PitchReader) float64
+	}{
+		{"saw", SawFunc, BandLimitedSawFunc},
+		{"square", SquareFunc, BandLimitedSquareFunc},
+		{"triangle", TriangleFunc, BandLimitedTriangleFunc},
+	}
+	for _, w := range waves {
+		for _, p := range []Pitch{C6, C7, C8} {
+			naive, limited := aliasing(p, w.naive), aliasing(p, w.bandl)
+			if limited > naive-margin {
+				t.Errorf("%v at %v: band limited aliasing %.1f dB, want at most %.1f dB (naive %.1f dB)",
+					w.name, p, limited, naive-margin, naive)
+			}
+		}
+	}
+}
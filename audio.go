@@ -14,20 +14,21 @@ import (
 	"github.com/oakmound/oak/v4/audio/pcm"
 	"github.com/oakmound/oak/v4/dlog"
 	"github.com/oakmound/oak/v4/event"
+	"github.com/oakmound/oak/v4/key"
 	"github.com/oakmound/oak/v4/mouse"
 	"github.com/oakmound/oak/v4/render"
 	"github.com/oakmound/oak/v4/scene"
 )
 
-func init() {
-	err := audio.InitDefault()
+func Play(ctx context.Context, r pcm.Reader) error {
+	w, err := NewFormatWriter(r.PCMFormat())
 	if err != nil {
-		panic(err)
+		return err
 	}
-}
-
-func Play(ctx context.Context, r pcm.Reader) error {
-	return audio.Play(ctx, r)
+	defer w.Close()
+	return audio.Play(ctx, r, func(po *audio.PlayOptions) {
+		po.Destination = w
+	})
 }
 
 var mainWaitFor = 5 * time.Second
@@ -77,10 +78,16 @@ func BufferLength(format pcm.Format) uint32 {
 	return uint32(float64(format.BytesPerSecond()) * audio.WriterBufferLengthInSeconds)
 }
 
+// VisualWriter opens a window drawing what is written to the Writer sent on ch. With DriverOffline
+// there is nothing to see, so the plain Writer is sent and VisualWriter blocks without a window.
 func VisualWriter(format pcm.Format, ch chan Writer) {
+	if CurrentDriver() == DriverOffline {
+		ch <- MustNewFormatWriter(format)
+		select {}
+	}
 	oak.AddScene("visualizer", scene.Scene{
 		Start: func(ctx *scene.Context) {
-			speaker := audio.MustNewWriter(format)
+			speaker := MustNewFormatWriter(format)
 			monitor := newPCMMonitor(ctx, speaker)
 			monitor.SetPos(0, 0)
 			render.Draw(monitor)
@@ -88,7 +95,7 @@ func VisualWriter(format pcm.Format, ch chan Writer) {
 		},
 	})
 	oak.Init("visualizer", func(c oak.Config) (oak.Config, error) {
-		c.Screen.Height = 240
+		c.Screen.Height = 440
 		c.Title = "Audio Visualizer"
 		c.Debug.Level = dlog.NONE.String()
 		c.TopMost = true
@@ -125,6 +132,14 @@ type pcmMonitor struct {
 
 var globalMagnification float64 = 1
 
+// The spectrum view below the waveform is toggled with S. L toggles between a log and linear
+// frequency axis, and W cycles through window functions.
+var (
+	globalSpectrum     = true
+	globalLogFrequency = true
+	globalWindow       = HannWindow
+)
+
 func newPCMMonitor(ctx *scene.Context, w pcm.Writer) *pcmMonitor {
 	fmt := w.PCMFormat()
 	pm := &pcmMonitor{
@@ -149,6 +164,18 @@ func newPCMMonitor(ctx *scene.Context, w pcm.Writer) *pcmMonitor {
 		globalMagnification += 0.5
 		return 0
 	})
+	event.GlobalBind(ctx, key.Down(key.S), func(_ key.Event) event.Response {
+		globalSpectrum = !globalSpectrum
+		return 0
+	})
+	event.GlobalBind(ctx, key.Down(key.L), func(_ key.Event) event.Response {
+		globalLogFrequency = !globalLogFrequency
+		return 0
+	})
+	event.GlobalBind(ctx, key.Down(key.W), func(_ key.Event) event.Response {
+		globalWindow = (globalWindow + 1) % Window(len(windowNames))
+		return 0
+	})
 	return pm
 }
 
@@ -179,33 +206,80 @@ func (pm *pcmMonitor) Draw(buf draw.Image, xOff, yOff float64) {
 	for x := 0.0; x < width; x++ {
 		wIndex := int(x) * xJump
 
-		var val int16
-		switch pm.Format.Bits {
-		case 8:
-			val8 := pm.written[wIndex]
-			val = int16(val8) << 8
-		case 16:
-			wIndex -= wIndex % 2
-			val = int16(pm.written[wIndex+1])<<8 +
-				int16(pm.written[wIndex])
-		case 32:
-			wIndex = wIndex - wIndex%4
-			val32 := int32(pm.written[wIndex+3])<<24 +
-				int32(pm.written[wIndex+2])<<16 +
-				int32(pm.written[wIndex+1])<<8 +
-				int32(pm.written[wIndex])
-			val = int16(val32 / int32(math.Pow(2, 16)))
+		bytesPerSample := int(pm.Format.Bits / 8)
+		if bytesPerSample == 0 {
+			return
 		}
+		wIndex -= wIndex % bytesPerSample
+		val := decodeSample(pm.written[wIndex:], pm.Format.Bits, EncodingOf(pm.Writer))
 
-		// -32768 -> 200
+		// -1 -> 200
 		// 0 -> 100
-		// 32768 -> 0
-		var y float64
-		if val < 0 {
-			y = height/2 + float64(val)*float64(height/2/-32768.0)
-		} else {
-			y = height/2 + -(float64(val) * float64(height/2/32768.0))
-		}
+		// 1 -> 0
+		y := height/2 - val*height/2
 		buf.Set(int(x+xOff+pm.X()), int(y+yOff+pm.Y()), c)
 	}
+	if globalSpectrum {
+		pm.drawSpectrum(buf, xOff+pm.X(), yOff+pm.Y()+height+20)
+	}
+}
+
+// spectrumSamples is how many samples the spectrum view analyzes. At 44.1 kHz, this separates
+// frequencies about 5 hz apart.
+const spectrumSamples = 8192
+
+// drawSpectrum draws the frequencies present in the most recently written audio, from 20 hz to
+// the nyquist frequency on the x axis and -100 to 0 decibels on the y axis.
+func (pm *pcmMonitor) drawSpectrum(buf draw.Image, xOff, yOff float64) {
+	const width = 640
+	const height = 200.0
+	const floor = -100.0
+	const minFrequency = 20.0
+
+	size := pm.Format.SampleSize()
+	if size == 0 {
+		return
+	}
+	frames := len(pm.written) / size
+	if frames > spectrumSamples {
+		frames = spectrumSamples
+	}
+	samples := make([]float64, frames)
+	at := pm.at - pm.at%size
+	enc := EncodingOf(pm.Writer)
+	for i := range samples {
+		// the oldest of the latest frames first
+		idx := (at - (frames-i)*size + len(pm.written)) % len(pm.written)
+		samples[i] = decodeSample(pm.written[idx:], pm.Format.Bits, enc)
+	}
+	spectrum := Spectrum(samples, globalWindow)
+	nyquist := float64(pm.Format.SampleRate) / 2
+
+	c := color.RGBA{120, 200, 255, 255}
+	frequencyAt := func(x float64) float64 {
+		if globalLogFrequency {
+			return minFrequency * math.Pow(nyquist/minFrequency, x/width)
+		}
+		return nyquist * x / width
+	}
+	binAt := func(freq float64) int {
+		return int(freq / nyquist * float64(len(spectrum)-1))
+	}
+	for x := 0.0; x < width; x++ {
+		lo, hi := binAt(frequencyAt(x)), binAt(frequencyAt(x+1))
+		if hi >= len(spectrum) {
+			hi = len(spectrum) - 1
+		}
+		if lo > hi {
+			lo = hi
+		}
+		db := floor
+		for bin := lo; bin <= hi; bin++ {
+			db = math.Max(db, spectrum[bin])
+		}
+		top := height * math.Min(db, 0) / floor
+		for y := top; y < height; y++ {
+			buf.Set(int(x+xOff), int(y+yOff), c)
+		}
+	}
 }
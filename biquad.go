@@ -0,0 +1,117 @@
+package daw
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// BiquadCoefficients are the normalized coefficients of a biquad filter's transfer function,
+// (B0 + B1/z + B2/z²) / (1 + A1/z + A2/z²).
+type BiquadCoefficients struct {
+	B0, B1, B2, A1, A2 float64
+}
+
+// DesignBiquad returns the coefficients of a filter at sampleRate acting around cutoff hz with a
+// resonance of q, following Robert Bristow-Johnson's Audio EQ Cookbook. gain is how many decibels
+// Peaking and shelving filters boost by; other modes ignore it. For shelving filters, a q of
+// ButterworthQ gives the steepest slope without overshoot.
+func DesignBiquad(mode FilterMode, cutoff, q, gain float64, sampleRate uint32) BiquadCoefficients {
+	w0 := 2 * math.Pi * cutoff / float64(sampleRate)
+	cos, alpha := math.Cos(w0), math.Sin(w0)/(2*q)
+	a := math.Pow(10, gain/40)
+	var b0, b1, b2, a0, a1, a2 float64
+	switch mode {
+	case LowPass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case HighPass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BandPass:
+		b0, b1, b2 = alpha, 0, -alpha
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case Notch:
+		b0, b1, b2 = 1, -2*cos, 1
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case AllPass:
+		b0, b1, b2 = 1-alpha, -2*cos, 1+alpha
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case Peaking:
+		b0, b1, b2 = 1+alpha*a, -2*cos, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cos, 1-alpha/a
+	case LowShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		b0, b1, b2 = a*((a+1)-(a-1)*cos+sq), 2*a*((a-1)-(a+1)*cos), a*((a+1)-(a-1)*cos-sq)
+		a0, a1, a2 = (a+1)+(a-1)*cos+sq, -2*((a-1)+(a+1)*cos), (a+1)+(a-1)*cos-sq
+	case HighShelf:
+		sq := 2 * math.Sqrt(a) * alpha
+		b0, b1, b2 = a*((a+1)+(a-1)*cos+sq), -2*a*((a-1)+(a+1)*cos), a*((a+1)+(a-1)*cos-sq)
+		a0, a1, a2 = (a+1)-(a-1)*cos+sq, 2*((a-1)-(a+1)*cos), (a+1)-(a-1)*cos-sq
+	default:
+		return BiquadCoefficients{B0: 1}
+	}
+	return BiquadCoefficients{
+		B0: b0 / a0,
+		B1: b1 / a0,
+		B2: b2 / a0,
+		A1: a1 / a0,
+		A2: a2 / a0,
+	}
+}
+
+// Response returns how a filter with these coefficients changes a sine wave of hz at sampleRate:
+// its magnitude is the change in amplitude, and its argument the change in phase.
+func (c BiquadCoefficients) Response(hz float64, sampleRate uint32) complex128 {
+	// evaluate the transfer function on the unit circle, at z⁻¹ = e^(-iω)
+	zInv := cmplx.Rect(1, -2*math.Pi*hz/float64(sampleRate))
+	zInv2 := zInv * zInv
+	num := complex(c.B0, 0) + complex(c.B1, 0)*zInv + complex(c.B2, 0)*zInv2
+	den := 1 + complex(c.A1, 0)*zInv + complex(c.A2, 0)*zInv2
+	return num / den
+}
+
+// Magnitude returns how many decibels a filter with these coefficients boosts or cuts hz by.
+func (c BiquadCoefficients) Magnitude(hz float64, sampleRate uint32) float64 {
+	return Decibels(cmplx.Abs(c.Response(hz, sampleRate)))
+}
+
+// biquad runs a filter on frames of audio, redesigning its coefficients when its settings change.
+type biquad struct {
+	coefficients BiquadCoefficients
+	// the settings coefficients were designed for
+	mode            FilterMode
+	cutoff, q, gain float64
+	sampleRate      uint32
+	// state holds two delayed values for each channel
+	state []float64
+}
+
+func (bq *biquad) design(mode FilterMode, cutoff, q, gain float64, sampleRate uint32) {
+	if mode == bq.mode && cutoff == bq.cutoff && q == bq.q && gain == bq.gain && sampleRate == bq.sampleRate {
+		return
+	}
+	bq.coefficients = DesignBiquad(mode, cutoff, q, gain, sampleRate)
+	bq.mode, bq.cutoff, bq.q, bq.gain, bq.sampleRate = mode, cutoff, q, gain, sampleRate
+}
+
+// reset clears the delayed values, as if the filter had only ever heard silence.
+func (bq *biquad) reset() {
+	for i := range bq.state {
+		bq.state[i] = 0
+	}
+}
+
+func (bq *biquad) process(frame []float64) {
+	if len(bq.state) != 2*len(frame) {
+		bq.state = make([]float64, 2*len(frame))
+	}
+	co := bq.coefficients
+	for c, x := range frame {
+		z1, z2 := &bq.state[2*c], &bq.state[2*c+1]
+		// transposed direct form II
+		y := co.B0*x + *z1
+		*z1 = co.B1*x - co.A1*y + *z2
+		*z2 = co.B2*x - co.A2*y
+		frame[c] = y
+	}
+}
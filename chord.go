@@ -6,6 +6,7 @@ type Chord []Step
 var (
 	MajorTriad       Chord = []Step{Major3, Perfect5}
 	MajorSixth       Chord = []Step{Major3, Perfect5, Major6}
+	MajorSeventh     Chord = []Step{Major3, Perfect5, Major7}
 	DominantSeventh  Chord = []Step{Major3, Perfect5, Minor7}
 	AugmentedTriad   Chord = []Step{Major3, Minor6, Major7}
 	AugmentedSeventh Chord = []Step{Major3, Minor6, Minor7}
@@ -26,3 +27,12 @@ func (c Chord) WithRoot(root Pitch) []Pitch {
 	}
 	return out
 }
+
+// WithRootIn returns the pitches of this chord above root in tuning t.
+func (c Chord) WithRootIn(root Pitch, t Tuning) []Pitch {
+	out := []Pitch{root}
+	for _, s := range c {
+		out = append(out, root.Tuned(t, s))
+	}
+	return out
+}
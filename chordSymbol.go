@@ -0,0 +1,307 @@
+package daw
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidChordSymbol is returned when parsing a chord symbol which is not understood.
+var ErrInvalidChordSymbol = errors.New("invalid chord symbol")
+
+// A ChordSymbol is a chord as written on a lead sheet, like "Cmaj7/E".
+type ChordSymbol struct {
+	Root PitchClass
+	// Quality is everything written between the root and bass, like "maj7" or "7b9".
+	Quality string
+	Chord   Chord
+	// Bass is the lowest pitch of the chord. It is Root unless the symbol has a slash.
+	Bass PitchClass
+}
+
+// leadSheetNames are how chord roots are usually spelled without a key.
+var leadSheetNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+
+func (cs ChordSymbol) String() string {
+	s := leadSheetNames[mod(int(cs.Root), 12)] + cs.Quality
+	if cs.Bass != cs.Root {
+		// spell the bass with sharps if the root's major key has sharps, like A/C#, else flats, like C/Bb
+		names := leadSheetNames
+		switch mod(int(cs.Root), 12) {
+		case 2, 4, 6, 7, 9, 11:
+			names = pitchClassNames
+		}
+		s += "/" + names[mod(int(cs.Bass), 12)]
+	}
+	return s
+}
+
+// Pitches returns the pitches of this chord with its root in an octave, where C4 is in octave 4.
+// If its bass is not its root, the bass is played in that octave instead, and the rest of the
+// chord is played closely above it.
+func (cs ChordSymbol) Pitches(octave int) []Pitch {
+	if cs.Bass == cs.Root {
+		return cs.Chord.WithRoot(cs.Root.In(octave))
+	}
+	bass := cs.Bass.In(octave)
+	ps := []Pitch{bass}
+	for _, p := range cs.Chord.WithRoot(cs.Root.In(octave)) {
+		class := p.Class()
+		if class == cs.Bass {
+			continue
+		}
+		p = class.In(octave)
+		if p < bass {
+			p *= 2
+		}
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+	return ps
+}
+
+// chordTones tracks which tones a chord symbol has while it is parsed. Zero means a tone is absent.
+type chordTones struct {
+	third, fifth, seventh Step
+	ninth, eleventh       Step
+	thirteenth            Step
+	added                 []Step
+}
+
+func (ct chordTones) chord() Chord {
+	steps := append([]Step{ct.third, ct.fifth, ct.seventh, ct.ninth, ct.eleventh, ct.thirteenth}, ct.added...)
+	var c Chord
+	seen := map[Step]bool{}
+	for _, s := range steps {
+		if s != 0 && !seen[s] {
+			seen[s] = true
+			c = append(c, s)
+		}
+	}
+	sort.Slice(c, func(i, j int) bool { return c[i] < c[j] })
+	return c
+}
+
+// ParseChord parses a lead sheet chord symbol, like "C", "F#m7", "Bbmaj9", "G7b9", "Dm7b5",
+// "Asus4", "Eadd9", "C6/9", "G13#11" or "Cmaj7/E". Without a seventh, 11 and 13 chords include
+// every extension below them, except that 13 chords with a major third leave out the 11th, which
+// clashes with it.
+func ParseChord(symbol string) (ChordSymbol, error) {
+	invalid := func() (ChordSymbol, error) {
+		return ChordSymbol{}, fmt.Errorf("%w: %q", ErrInvalidChordSymbol, symbol)
+	}
+	root, s, ok := parsePitchClass(symbol)
+	if !ok {
+		return invalid()
+	}
+	cs := ChordSymbol{Root: root, Bass: root}
+	// 6/9 is the only symbol with a slash that is not a bass note
+	if i := strings.LastIndex(s, "/"); i != -1 && !strings.HasPrefix(s[i+1:], "9") {
+		bass, rest, ok := parsePitchClass(s[i+1:])
+		if !ok || rest != "" {
+			return invalid()
+		}
+		cs.Bass = bass
+		s = s[:i]
+	}
+	cs.Quality = s
+
+	ct := chordTones{third: Major3, fifth: Perfect5}
+	major := false
+	switch {
+	case hasAnyPrefix(&s, "maj", "Maj", "M", "Δ"):
+		major = true
+	case hasAnyPrefix(&s, "min", "mi", "m", "-"):
+		ct.third = Minor3
+		// mM7 and m(maj7)
+		if hasAnyPrefix(&s, "Maj", "maj", "(maj", "M") {
+			major = true
+		}
+	case hasAnyPrefix(&s, "dim", "°", "o"):
+		ct.third, ct.fifth = Minor3, Tritone
+		if hasAnyPrefix(&s, "7") {
+			ct.seventh = Major6
+		}
+	case hasAnyPrefix(&s, "ø"):
+		ct.third, ct.fifth, ct.seventh = Minor3, Tritone, Minor7
+		hasAnyPrefix(&s, "7")
+	case hasAnyPrefix(&s, "aug", "+"):
+		ct.fifth = Minor6
+	case hasAnyPrefix(&s, "5"):
+		if s != "" {
+			return invalid()
+		}
+		ct.third = 0
+		return cs.finish(ct), nil
+	}
+
+	seventh := Minor7
+	if major {
+		seventh = Major7
+	}
+	switch {
+	case hasAnyPrefix(&s, "6/9", "69"):
+		ct.added = append(ct.added, Major6, Octave+Major2)
+	case hasAnyPrefix(&s, "6"):
+		ct.added = append(ct.added, Major6)
+	case hasAnyPrefix(&s, "7"):
+		ct.seventh = seventh
+	case hasAnyPrefix(&s, "9"):
+		ct.seventh, ct.ninth = seventh, Octave+Major2
+	case hasAnyPrefix(&s, "11"):
+		ct.seventh, ct.ninth, ct.eleventh = seventh, Octave+Major2, Octave+Perfect4
+	case hasAnyPrefix(&s, "13"):
+		ct.seventh, ct.ninth, ct.thirteenth = seventh, Octave+Major2, Octave+Major6
+		if ct.third == Minor3 {
+			ct.eleventh = Octave + Perfect4
+		}
+	}
+
+	for s != "" {
+		s = strings.TrimLeft(s, "(), ")
+		switch {
+		case s == "":
+		case hasAnyPrefix(&s, "sus2"):
+			ct.third = Major2
+		case hasAnyPrefix(&s, "sus4", "sus"):
+			ct.third = Perfect4
+		case hasAnyPrefix(&s, "add2"):
+			ct.added = append(ct.added, Major2)
+		case hasAnyPrefix(&s, "add4"):
+			ct.added = append(ct.added, Perfect4)
+		case hasAnyPrefix(&s, "add9"):
+			ct.added = append(ct.added, Octave+Major2)
+		case hasAnyPrefix(&s, "add11"):
+			ct.added = append(ct.added, Octave+Perfect4)
+		case hasAnyPrefix(&s, "add13"):
+			ct.added = append(ct.added, Octave+Major6)
+		case hasAnyPrefix(&s, "no3"):
+			ct.third = 0
+		case hasAnyPrefix(&s, "no5"):
+			ct.fifth = 0
+		case hasAnyPrefix(&s, "b5", "-5"):
+			ct.fifth = Tritone
+		case hasAnyPrefix(&s, "#5", "+5"):
+			ct.fifth = Minor6
+		case hasAnyPrefix(&s, "b9", "-9"):
+			ct.ninth = Octave + Minor2
+		case hasAnyPrefix(&s, "#9", "+9"):
+			ct.ninth = Octave + Minor3
+		case hasAnyPrefix(&s, "#11", "+11"):
+			ct.eleventh = Octave + Tritone
+		case hasAnyPrefix(&s, "b13", "-13"):
+			ct.thirteenth = Octave + Minor6
+		default:
+			return invalid()
+		}
+	}
+	return cs.finish(ct), nil
+}
+
+func (cs ChordSymbol) finish(ct chordTones) ChordSymbol {
+	cs.Chord = ct.chord()
+	return cs
+}
+
+// hasAnyPrefix reports whether s starts with any of prefixes, and if so, removes it from s.
+func hasAnyPrefix(s *string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(*s, p) {
+			*s = (*s)[len(p):]
+			return true
+		}
+	}
+	return false
+}
+
+// parsePitchClass reads a letter name and any sharps or flats from the start of s, returning the
+// rest of s.
+func parsePitchClass(s string) (pc PitchClass, rest string, ok bool) {
+	semitones, rest, ok := parseLetterName(s)
+	return PitchClass(mod(semitones, 12)), rest, ok
+}
+
+// chordQualities are the qualities NameChord will name chords with, simplest first.
+var chordQualities = []string{
+	"", "m", "5", "dim", "aug", "sus4", "sus2",
+	"7", "maj7", "m7", "m7b5", "dim7", "6", "m6", "mMaj7", "aug7", "7sus4", "add9", "madd9",
+	"9", "maj9", "m9", "6/9", "7b9", "7#9", "7b5", "7#5", "7#11", "11", "m11", "13", "maj13", "m13",
+}
+
+// chordQualitySets holds the pitch classes above the root of each of chordQualities.
+var chordQualitySets = func() []uint16 {
+	sets := make([]uint16, len(chordQualities))
+	for i, q := range chordQualities {
+		cs, err := ParseChord("C" + q)
+		if err != nil {
+			panic(err)
+		}
+		sets[i] = pitchClassSet(cs.Chord.WithRoot(C4))
+	}
+	return sets
+}()
+
+// pitchClassSet returns a bit set of the pitch classes of ps.
+func pitchClassSet(ps []Pitch) uint16 {
+	var set uint16
+	for _, p := range ps {
+		set |= 1 << p.Class()
+	}
+	return set
+}
+
+// NameChord returns the chord symbol which best names a set of pitches, ignoring their octaves and
+// order except that the lowest pitch is the bass. Chords without a fifth are named as if they had
+// one. It returns false if the pitches do not form a chord it knows.
+func NameChord(ps []Pitch) (ChordSymbol, bool) {
+	var sounding []Pitch
+	for _, p := range ps {
+		if p > 0 {
+			sounding = append(sounding, p)
+		}
+	}
+	if len(sounding) == 0 {
+		return ChordSymbol{}, false
+	}
+	bass := sounding[0]
+	for _, p := range sounding {
+		if p < bass {
+			bass = p
+		}
+	}
+	set := pitchClassSet(sounding)
+	if bits.OnesCount16(set) < 2 {
+		return ChordSymbol{}, false
+	}
+	// prefer the bass as the root, then simpler qualities
+	roots := []PitchClass{bass.Class()}
+	for pc := PitchClass(0); pc < 12; pc++ {
+		if set&(1<<pc) != 0 && pc != bass.Class() {
+			roots = append(roots, pc)
+		}
+	}
+	for _, withFifth := range []bool{false, true} {
+		for _, root := range roots {
+			rotated := rotatePitchClassSet(set, -int(root))
+			if withFifth {
+				rotated |= 1 << Perfect5
+			}
+			for i, qs := range chordQualitySets {
+				if qs != rotated {
+					continue
+				}
+				cs, _ := ParseChord(leadSheetNames[root] + chordQualities[i])
+				cs.Bass = bass.Class()
+				return cs, true
+			}
+		}
+	}
+	return ChordSymbol{}, false
+}
+
+func rotatePitchClassSet(set uint16, by int) uint16 {
+	by = mod(by, 12)
+	return (set>>(12-by) | set<<by) & 0xFFF
+}
@@ -0,0 +1,81 @@
+package daw
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		symbol string
+		root   PitchClass
+		bass   PitchClass
+		chord  Chord
+	}{
+		{"C", 0, 0, Chord{4, 7}},
+		{"F#m7", 6, 6, Chord{3, 7, 10}},
+		{"Bbmaj9", 10, 10, Chord{4, 7, 11, 14}},
+		{"G7b9", 7, 7, Chord{4, 7, 10, 13}},
+		{"Dm7b5", 2, 2, Chord{3, 6, 10}},
+		{"Asus4", 9, 9, Chord{5, 7}},
+		{"Eadd9", 4, 4, Chord{4, 7, 14}},
+		{"C6/9", 0, 0, Chord{4, 7, 9, 14}},
+		{"G13#11", 7, 7, Chord{4, 7, 10, 14, 18, 21}},
+		{"Cmaj7/E", 0, 4, Chord{4, 7, 11}},
+		{"C11", 0, 0, Chord{4, 7, 10, 14, 17}},
+		// only 13 chords with a minor third keep the 11th
+		{"C13", 0, 0, Chord{4, 7, 10, 14, 21}},
+		{"Cmaj13", 0, 0, Chord{4, 7, 11, 14, 21}},
+		{"Cm13", 0, 0, Chord{3, 7, 10, 14, 17, 21}},
+		{"C5", 0, 0, Chord{7}},
+		{"C5/G", 0, 7, Chord{7}},
+	}
+	for _, tt := range tests {
+		cs, err := ParseChord(tt.symbol)
+		if err != nil {
+			t.Errorf("%v: %v", tt.symbol, err)
+			continue
+		}
+		if cs.Root != tt.root || cs.Bass != tt.bass || !reflect.DeepEqual(cs.Chord, tt.chord) {
+			t.Errorf("%v: got root %v bass %v chord %v, want root %v bass %v chord %v",
+				tt.symbol, cs.Root, cs.Bass, cs.Chord, tt.root, tt.bass, tt.chord)
+		}
+		if cs.String() != tt.symbol {
+			t.Errorf("%v: formatted as %v", tt.symbol, cs)
+		}
+	}
+}
+
+func TestParseChordInvalid(t *testing.T) {
+	for _, symbol := range []string{"", "H", "C5xyz", "Cmaj7xyz", "C/", "C/X"} {
+		if _, err := ParseChord(symbol); !errors.Is(err, ErrInvalidChordSymbol) {
+			t.Errorf("%q: got error %v, want %v", symbol, err, ErrInvalidChordSymbol)
+		}
+	}
+}
+
+func TestNameChord(t *testing.T) {
+	tests := []struct {
+		pitches []Pitch
+		want    string
+	}{
+		{[]Pitch{C4, E4, G4}, "C"},
+		{[]Pitch{E3, C4, G4}, "C/E"},
+		{[]Pitch{C4, G4, E5}, "C"},
+		{[]Pitch{A3, C4, E4, G4}, "Am7"},
+		{[]Pitch{G3, B3, D4, F4}, "G7"},
+		{[]Pitch{C4, G4}, "C5"},
+		// without a fifth, named as if it had one
+		{[]Pitch{C4, E4, B4b}, "C7"},
+	}
+	for _, tt := range tests {
+		cs, ok := NameChord(tt.pitches)
+		if !ok || cs.String() != tt.want {
+			t.Errorf("%v: got %v, %v, want %v", tt.pitches, cs, ok, tt.want)
+		}
+	}
+	if cs, ok := NameChord([]Pitch{C4}); ok {
+		t.Errorf("a single pitch was named %v", cs)
+	}
+}
@@ -0,0 +1,267 @@
+package daw
+
+import (
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// An ImpulseResponse is a recording of how a room, or a device like a guitar cabinet, responds to a
+// single click. Convolving audio with it makes that audio sound as if it were played there.
+type ImpulseResponse struct {
+	SampleRate uint32
+	// Channels holds the samples of each channel, scaled to [-1, 1].
+	Channels [][]float64
+}
+
+// LoadImpulseResponse reads the named WAV file; see ReadImpulseResponse.
+func LoadImpulseResponse(name string) (ImpulseResponse, error) {
+	fl, err := os.Open(name)
+	if err != nil {
+		return ImpulseResponse{}, err
+	}
+	defer fl.Close()
+	return ReadImpulseResponse(fl)
+}
+
+// ReadImpulseResponse reads an impulse response from a WAV file of 8, 16, 24 or 32 bit integer
+// samples, or 32 or 64 bit float samples.
+func ReadImpulseResponse(r io.Reader) (ImpulseResponse, error) {
+	format, enc, data, err := readWAV(r)
+	if err != nil {
+		return ImpulseResponse{}, err
+	}
+	size := format.SampleSize()
+	ir := ImpulseResponse{
+		SampleRate: format.SampleRate,
+		Channels:   make([][]float64, format.Channels),
+	}
+	frame := make([]float64, format.Channels)
+	for i := 0; i+size <= len(data); i += size {
+		decodeFrame(data[i:], format, enc, frame)
+		for c, v := range frame {
+			ir.Channels[c] = append(ir.Channels[c], v)
+		}
+	}
+	return ir, nil
+}
+
+// Len returns how many samples long each channel of this impulse response is.
+func (ir ImpulseResponse) Len() int {
+	if len(ir.Channels) == 0 {
+		return 0
+	}
+	return len(ir.Channels[0])
+}
+
+func (ir ImpulseResponse) Duration() time.Duration {
+	if ir.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(ir.Len()) / float64(ir.SampleRate) * float64(time.Second))
+}
+
+// Resample returns this impulse response at another sample rate, linearly interpolating between
+// samples.
+func (ir ImpulseResponse) Resample(sampleRate uint32) ImpulseResponse {
+	if ir.SampleRate == sampleRate || ir.SampleRate == 0 || ir.Len() == 0 {
+		return ir
+	}
+	ratio := float64(ir.SampleRate) / float64(sampleRate)
+	n := int(float64(ir.Len()) / ratio)
+	out := ImpulseResponse{
+		SampleRate: sampleRate,
+		Channels:   make([][]float64, len(ir.Channels)),
+	}
+	for c, samples := range ir.Channels {
+		out.Channels[c] = make([]float64, n)
+		for i := range out.Channels[c] {
+			pos := float64(i) * ratio
+			j := int(pos)
+			t := pos - float64(j)
+			next := samples[len(samples)-1]
+			if j+1 < len(samples) {
+				next = samples[j+1]
+			}
+			out.Channels[c][i] = samples[j]*(1-t) + next*t
+		}
+	}
+	return out
+}
+
+// Normalized returns this impulse response scaled so that its loudest channel has a total energy
+// of 1, so that convolving with it keeps audio at about the same level.
+func (ir ImpulseResponse) Normalized() ImpulseResponse {
+	var energy float64
+	for _, samples := range ir.Channels {
+		var e float64
+		for _, v := range samples {
+			e += v * v
+		}
+		energy = math.Max(energy, e)
+	}
+	if energy == 0 {
+		return ir
+	}
+	scale := 1 / math.Sqrt(energy)
+	out := ImpulseResponse{
+		SampleRate: ir.SampleRate,
+		Channels:   make([][]float64, len(ir.Channels)),
+	}
+	for c, samples := range ir.Channels {
+		out.Channels[c] = make([]float64, len(samples))
+		for i, v := range samples {
+			out.Channels[c][i] = v * scale
+		}
+	}
+	return out
+}
+
+var _ pcm.Reader = &Convolver{}
+
+// ConvolutionBlockSize is how many frames a Convolver processes at once. Its output is delayed by
+// this many frames; smaller blocks lower that delay but cost more to process. It is
+// rounded up to a power of two.
+var ConvolutionBlockSize = 512
+
+// A Convolver convolves another reader with an impulse response, using partitioned fast fourier
+// transforms so that even impulse responses several seconds long can be applied as audio plays.
+// Each channel is convolved with the channel of the impulse response of the same index, wrapping
+// around, so a mono impulse response applies to every channel. Its mix may be changed from any
+// goroutine while it is being read from. After its input ends, a Convolver keeps playing until it
+// has faded away.
+type Convolver struct {
+	effect
+
+	mix *Param
+
+	blockSize int
+	length    int
+	channels  []convolverChannel
+	// fill is how many frames of the current block have been read
+	fill int
+}
+
+// convolverChannel convolves one channel by uniformly partitioned overlap-save: each block of input
+// is transformed once, and multiplied with the transform of each block-sized partition of the
+// impulse response against the transforms of as many previous input blocks.
+type convolverChannel struct {
+	// partitions holds the transform of each partition of the impulse response
+	partitions [][]complex128
+	// history holds the transforms of the most recent input blocks, newest at newest
+	history [][]complex128
+	newest  int
+	// input holds the previous and current blocks of input
+	input  []float64
+	output []float64
+	sum    []complex128
+}
+
+// NewConvolver convolves r with ir, resampled to r's sample rate if needed. Its output is only
+// the convolved signal until its mix is set.
+func NewConvolver(r pcm.Reader, ir ImpulseResponse) *Convolver {
+	format := r.PCMFormat()
+	ir = ir.Resample(format.SampleRate)
+	blockSize := nextPowerOfTwo(ConvolutionBlockSize)
+	cv := &Convolver{
+		effect:    effect{Reader: r, tail: new(tail)},
+		mix:       NewParam(1, ReverbSmoothing),
+		blockSize: blockSize,
+		length:    ir.Len(),
+		channels:  make([]convolverChannel, format.Channels),
+	}
+	partitions := (ir.Len() + blockSize - 1) / blockSize
+	for c := range cv.channels {
+		ch := &cv.channels[c]
+		ch.input = make([]float64, 2*blockSize)
+		ch.output = make([]float64, blockSize)
+		ch.sum = make([]complex128, 2*blockSize)
+		if len(ir.Channels) == 0 {
+			continue
+		}
+		samples := ir.Channels[c%len(ir.Channels)]
+		for p := 0; p < partitions; p++ {
+			// each partition is zero padded to twice the block size, so the circular convolution
+			// of a transform holds a whole block of linear convolution
+			x := make([]complex128, 2*blockSize)
+			for i := 0; i < blockSize && p*blockSize+i < len(samples); i++ {
+				x[i] = complex(samples[p*blockSize+i], 0)
+			}
+			fft(x)
+			ch.partitions = append(ch.partitions, x[:blockSize+1])
+			ch.history = append(ch.history, make([]complex128, blockSize+1))
+		}
+	}
+	return cv
+}
+
+// SetMix sets how much of the output is the convolved signal, from 0, only the input, to 1, only
+// the convolved signal.
+func (cv *Convolver) SetMix(mix float64) {
+	cv.mix.Set(mix)
+}
+
+func (cv *Convolver) Mix() float64 {
+	return cv.mix.Get()
+}
+
+func (cv *Convolver) ReadPCM(b []byte) (n int, err error) {
+	return cv.process(b, func(frame []float64) {
+		mix := cv.value(cv.mix)
+		for c, v := range frame {
+			if c >= len(cv.channels) {
+				break
+			}
+			ch := &cv.channels[c]
+			ch.input[cv.blockSize+cv.fill] = v
+			// the convolved block lags the input by a block, so the input is played from the
+			// previous block to stay in line with it
+			frame[c] = ch.input[cv.fill]*(1-mix) + ch.output[cv.fill]*mix
+		}
+		cv.tail.listen(frame, cv.length+2*cv.blockSize)
+		cv.fill++
+		if cv.fill == cv.blockSize {
+			cv.fill = 0
+			for c := range cv.channels {
+				cv.channels[c].process(cv.blockSize)
+			}
+		}
+	})
+}
+
+// process convolves the block of input just completed, replacing output with the next block.
+func (ch *convolverChannel) process(blockSize int) {
+	if len(ch.partitions) == 0 {
+		copy(ch.input, ch.input[blockSize:])
+		return
+	}
+	x := ch.sum
+	for i, v := range ch.input {
+		x[i] = complex(v, 0)
+	}
+	fft(x)
+	// the input is real, so only the first half of its transform is needed
+	copy(ch.history[ch.newest], x[:blockSize+1])
+	for i := range x {
+		x[i] = 0
+	}
+	for p, h := range ch.partitions {
+		in := ch.history[mod(ch.newest-p, len(ch.history))]
+		for i := range h {
+			x[i] += in[i] * h[i]
+		}
+	}
+	for i := 1; i < blockSize; i++ {
+		x[2*blockSize-i] = complex(real(x[i]), -imag(x[i]))
+	}
+	ifft(x)
+	// the first half of the result wraps around, the second is the convolved block
+	for i := range ch.output {
+		ch.output[i] = real(x[blockSize+i])
+	}
+	copy(ch.input, ch.input[blockSize:])
+	ch.newest = (ch.newest + 1) % len(ch.history)
+}
@@ -0,0 +1,238 @@
+package daw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// riff builds a WAV file from chunks, each an id followed by its data.
+func riff(chunks ...string) []byte {
+	body := []byte("WAVE")
+	for i := 0; i < len(chunks); i += 2 {
+		body = append(body, chunks[i]...)
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(chunks[i+1])))
+		body = append(body, chunks[i+1]...)
+		if len(chunks[i+1])%2 == 1 {
+			body = append(body, 0)
+		}
+	}
+	out := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	return append(out, body...)
+}
+
+// fmtChunk returns the data of a format chunk. If extensible, the tag is written in its sub format.
+func fmtChunk(tag uint16, format pcm.Format, extensible bool) string {
+	outer := tag
+	if extensible {
+		outer = wavFormatExtensible
+	}
+	b := binary.LittleEndian.AppendUint16(nil, outer)
+	b = binary.LittleEndian.AppendUint16(b, format.Channels)
+	b = binary.LittleEndian.AppendUint32(b, format.SampleRate)
+	b = binary.LittleEndian.AppendUint32(b, format.BytesPerSecond())
+	b = binary.LittleEndian.AppendUint16(b, uint16(format.SampleSize()))
+	b = binary.LittleEndian.AppendUint16(b, format.Bits)
+	if extensible {
+		b = binary.LittleEndian.AppendUint16(b, 22)
+		b = binary.LittleEndian.AppendUint16(b, format.Bits)
+		b = binary.LittleEndian.AppendUint32(b, 3)
+		b = binary.LittleEndian.AppendUint16(b, tag)
+		b = append(b, wavSubFormatSuffix...)
+	}
+	return string(b)
+}
+
+func samples(format pcm.Format, enc Encoding, frames ...[]float64) string {
+	b := make([]byte, format.SampleSize()*len(frames))
+	for i, f := range frames {
+		encodeFrame(b[i*format.SampleSize():], format, enc, f)
+	}
+	return string(b)
+}
+
+func TestReadImpulseResponse(t *testing.T) {
+	stereo16 := pcm.Format{SampleRate: 48000, Channels: 2, Bits: 16}
+	stereo24 := pcm.Format{SampleRate: 48000, Channels: 2, Bits: 24}
+	monoFloat := pcm.Format{SampleRate: 44100, Channels: 1, Bits: 32}
+	tests := []struct {
+		name string
+		data []byte
+		want ImpulseResponse
+	}{
+		{
+			"plain",
+			riff("fmt ", fmtChunk(wavFormatPCM, stereo16, false), "data", samples(stereo16, EncodingInt, []float64{.5, -.5}, []float64{.25, 0})),
+			ImpulseResponse{SampleRate: 48000, Channels: [][]float64{{.5, .25}, {-.5, 0}}},
+		},
+		{
+			"extra chunks",
+			riff("LIST", "INFOISFT\x05\x00\x00\x00tool\x00", "fmt ", fmtChunk(wavFormatPCM, stereo16, false),
+				"bext", "odd", "data", samples(stereo16, EncodingInt, []float64{1, 0})),
+			ImpulseResponse{SampleRate: 48000, Channels: [][]float64{{1}, {0}}},
+		},
+		{
+			"extensible int",
+			riff("fmt ", fmtChunk(wavFormatPCM, stereo24, true), "data", samples(stereo24, EncodingInt, []float64{.5, .75})),
+			ImpulseResponse{SampleRate: 48000, Channels: [][]float64{{.5}, {.75}}},
+		},
+		{
+			"extensible float",
+			riff("JUNK", "\x00\x00\x00\x00", "fmt ", fmtChunk(wavFormatFloat, monoFloat, true), "data", samples(monoFloat, EncodingFloat, []float64{-.125}, []float64{.5})),
+			ImpulseResponse{SampleRate: 44100, Channels: [][]float64{{-.125, .5}}},
+		},
+	}
+	for _, tt := range tests {
+		ir, err := ReadImpulseResponse(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if ir.SampleRate != tt.want.SampleRate || len(ir.Channels) != len(tt.want.Channels) || ir.Len() != tt.want.Len() {
+			t.Errorf("%v: got %+v, want %+v", tt.name, ir, tt.want)
+			continue
+		}
+		for c := range ir.Channels {
+			for i, v := range ir.Channels[c] {
+				if math.Abs(v-tt.want.Channels[c][i]) > 1e-4 {
+					t.Errorf("%v: got %+v, want %+v", tt.name, ir, tt.want)
+				}
+			}
+		}
+	}
+}
+
+func TestReadImpulseResponseInvalid(t *testing.T) {
+	stereo16 := pcm.Format{SampleRate: 48000, Channels: 2, Bits: 16}
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"empty", nil, ErrInvalidWAV},
+		{"not riff", []byte("RIFX\x00\x00\x00\x00WAVE"), ErrInvalidWAV},
+		{"no data", riff("fmt ", fmtChunk(wavFormatPCM, stereo16, false)), ErrInvalidWAV},
+		{"data first", riff("data", "\x00\x00\x00\x00", "fmt ", fmtChunk(wavFormatPCM, stereo16, false)), ErrInvalidWAV},
+		{"mp3", riff("fmt ", fmtChunk(0x55, stereo16, false), "data", ""), ErrUnsupportedWAVFormat},
+		{"12 bit", riff("fmt ", fmtChunk(wavFormatPCM, pcm.Format{SampleRate: 48000, Channels: 1, Bits: 12}, false), "data", ""), ErrUnsupportedWAVFormat},
+	}
+	for _, tt := range tests {
+		if _, err := ReadImpulseResponse(bytes.NewReader(tt.data)); !errors.Is(err, tt.err) {
+			t.Errorf("%v: got error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// convolve returns the direct convolution of x and h.
+func convolve(x, h []float64) []float64 {
+	y := make([]float64, len(x)+len(h)-1)
+	for i, xv := range x {
+		for j, hv := range h {
+			y[i+j] += xv * hv
+		}
+	}
+	return y
+}
+
+func TestConvolver(t *testing.T) {
+	defer func(size int) { ConvolutionBlockSize = size }(ConvolutionBlockSize)
+	rng := rand.New(rand.NewSource(1))
+	// noise returns n samples of noise, scaled so that convolving with it can't clip
+	noise := func(n int, scale float64) []float64 {
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = (rng.Float64() - .5) * scale
+		}
+		return out
+	}
+	tests := []struct {
+		name      string
+		blockSize int
+		// latency is the block size, rounded up to a power of two
+		latency  int
+		channels int
+		ir       [][]float64
+	}{
+		{"one partition", 64, 64, 1, [][]float64{noise(50, 1./50)}},
+		{"several partitions", 64, 64, 2, [][]float64{noise(300, 1./300), noise(129, 1./129)}},
+		{"mono response on stereo", 32, 32, 2, [][]float64{noise(100, 1./100)}},
+		{"rounded block size", 100, 128, 1, [][]float64{noise(500, 1./500)}},
+	}
+	for _, tt := range tests {
+		ConvolutionBlockSize = tt.blockSize
+		input := make([][]float64, tt.channels)
+		for c := range input {
+			input[c] = noise(1000, 1)
+		}
+		interleaved := make([]float64, 0, 1000*tt.channels)
+		for i := 0; i < 1000; i++ {
+			for c := range input {
+				interleaved = append(interleaved, input[c][i])
+			}
+		}
+		ir := ImpulseResponse{SampleRate: 44100, Channels: tt.ir}
+		cv := NewConvolver(newSamplesReader(44100, uint16(tt.channels), interleaved), ir)
+		frames := 1000 + ir.Len() + tt.latency
+		out := readSamples(t, cv, frames*tt.channels)
+		if len(out) < frames*tt.channels {
+			t.Errorf("%v: played %v frames, want at least %v", tt.name, len(out)/tt.channels, frames)
+			continue
+		}
+	channels:
+		for c := range input {
+			want := convolve(input[c], tt.ir[c%len(tt.ir)])
+			for i := 0; i < frames; i++ {
+				var w float64
+				if j := i - tt.latency; j >= 0 && j < len(want) {
+					w = want[j]
+				}
+				if got := out[i*tt.channels+c]; math.Abs(got-w) > 1e-9 {
+					t.Errorf("%v: channel %v frame %v: got %v, want %v", tt.name, c, i, got, w)
+					continue channels
+				}
+			}
+		}
+	}
+}
+
+func TestConvolverDry(t *testing.T) {
+	defer func(size int) { ConvolutionBlockSize = size }(ConvolutionBlockSize)
+	ConvolutionBlockSize = 64
+	in := stereoSine(44100, 440)[:2000]
+	cv := NewConvolver(newSamplesReader(44100, 2, append([]float64{}, in...)), ImpulseResponse{
+		SampleRate: 44100,
+		Channels:   [][]float64{{1, .5, .25}},
+	})
+	cv.SetMix(0)
+	// the input is delayed a block of stereo frames to line up with the convolved signal
+	const delay = 2 * 64
+	out := readSamples(t, cv, len(in)+delay)
+	for i, v := range out {
+		var want float64
+		if j := i - delay; j >= 0 && j < len(in) {
+			want = in[j]
+		}
+		if v != want {
+			t.Fatalf("sample %v: got %v, want %v", i, v, want)
+		}
+	}
+}
+
+func TestConvolverTail(t *testing.T) {
+	defer func(size int) { ConvolutionBlockSize = size }(ConvolutionBlockSize)
+	ConvolutionBlockSize = 64
+	ir := ImpulseResponse{SampleRate: 44100, Channels: [][]float64{make([]float64, 2000)}}
+	ir.Channels[0][1999] = 1
+	cv := NewConvolver(newSamplesReader(44100, 1, impulse(1, 1)), ir)
+	played, ended := playedFrames(t, cv, 32, 44100)
+	// the impulse comes back at the end of the response, a block late, then the tail waits out
+	// the response's length in silence
+	if !ended || played < 2000+64 || played > 2*2000+4*64 {
+		t.Errorf("played %v frames, ended %v; want the response to be heard then end", played, ended)
+	}
+}
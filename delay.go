@@ -0,0 +1,178 @@
+package daw
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &Delay{}
+
+// MaxDelay is the longest delay time a Delay can hold.
+var MaxDelay = 4 * time.Second
+
+// DelaySmoothing is how long changes to a Delay's time, feedback and mix take to fully apply. A
+// changing delay time bends the pitch of what is already echoing, like tape.
+var DelaySmoothing = 50 * time.Millisecond
+
+// A Delay echoes another reader. Each echo is fed back into the delay, quieter, to echo again.
+// Its settings may be changed from any goroutine while it is being read from. After its input
+// ends, a Delay keeps playing until its echoes have faded away.
+type Delay struct {
+	effect
+
+	pingPong atomic.Bool
+	time     *Param
+	feedback *Param
+	mix      *Param
+
+	filtered   atomic.Bool
+	filterMode atomic.Int64
+	filter     filterControls
+	biquad     biquad
+
+	// buf holds MaxDelay of interleaved frames of what was written into the delay
+	buf      []float64
+	pos      int
+	delayed  []float64
+	returned []float64
+}
+
+// NewDelay echoes r every delay, with each echo feedback times as loud as the last, mixed in
+// equally with r.
+func NewDelay(r pcm.Reader, delay time.Duration, feedback float64) *Delay {
+	return &Delay{
+		effect:   effect{Reader: r, tail: new(tail)},
+		time:     NewParam(delay.Seconds(), DelaySmoothing),
+		feedback: NewParam(feedback, DelaySmoothing),
+		mix:      NewParam(.5, DelaySmoothing),
+		filter:   newFilterControls(20000, ButterworthQ, 0),
+	}
+}
+
+// SetTime sets how long until each echo, up to MaxDelay.
+func (d *Delay) SetTime(delay time.Duration) {
+	d.time.Set(delay.Seconds())
+}
+
+// SetBeats sets the time until each echo to a number of beats at a tempo, like Dotted(EighthNote)
+// at 120 bpm.
+func (d *Delay) SetBeats(beats, bpm float64) {
+	d.SetTime(BeatDuration(beats, bpm))
+}
+
+func (d *Delay) Time() time.Duration {
+	return time.Duration(d.time.Get() * float64(time.Second))
+}
+
+// SetFeedback sets how loud each echo is relative to the one before it. Values of 1 or more
+// echo forever.
+func (d *Delay) SetFeedback(feedback float64) {
+	d.feedback.Set(feedback)
+}
+
+func (d *Delay) Feedback() float64 {
+	return d.feedback.Get()
+}
+
+// SetMix sets how much of the output is echoes, from 0, only the input, to 1, only echoes.
+func (d *Delay) SetMix(mix float64) {
+	d.mix.Set(mix)
+}
+
+func (d *Delay) Mix() float64 {
+	return d.mix.Get()
+}
+
+// SetPingPong sets whether echoes alternate between channels, starting from the first.
+func (d *Delay) SetPingPong(pingPong bool) {
+	d.pingPong.Store(pingPong)
+}
+
+func (d *Delay) PingPong() bool {
+	return d.pingPong.Load()
+}
+
+// SetFilter filters each echo before it is fed back, so that, for example, with a LowPass filter
+// echoes grow darker as they fade.
+func (d *Delay) SetFilter(mode FilterMode, cutoff, resonance float64) {
+	d.filterMode.Store(int64(mode))
+	d.filter.SetCutoff(cutoff)
+	d.filter.SetResonance(resonance)
+	d.filtered.Store(true)
+}
+
+// RemoveFilter stops filtering echoes.
+func (d *Delay) RemoveFilter() {
+	d.filtered.Store(false)
+}
+
+func (d *Delay) ReadPCM(b []byte) (n int, err error) {
+	format := d.PCMFormat()
+	channels := int(format.Channels)
+	rate := float64(format.SampleRate)
+	// room for the longest delay and the samples around it used to interpolate
+	frames := int(MaxDelay.Seconds()*rate) + 4
+	if len(d.buf) != frames*channels {
+		d.buf = make([]float64, frames*channels)
+		d.delayed = make([]float64, channels)
+		d.returned = make([]float64, channels)
+		d.pos = 0
+	}
+	return d.process(b, func(frame []float64) {
+		delay := math.Max(3, math.Min(d.value(d.time)*rate, float64(frames-3)))
+		feedback := d.value(d.feedback)
+		mix := d.value(d.mix)
+		cutoff, q, gain := d.filter.next(format.SampleRate)
+
+		for c := range frame {
+			d.delayed[c] = d.read(c, channels, float64(d.pos)-delay)
+		}
+		copy(d.returned, d.delayed)
+		if d.filtered.Load() {
+			d.biquad.design(FilterMode(d.filterMode.Load()), cutoff, q, gain, format.SampleRate)
+			d.biquad.process(d.returned)
+		}
+
+		at := d.pos * channels
+		if d.pingPong.Load() && channels > 1 {
+			// the input enters the first channel, and each channel echoes into the next
+			var mono float64
+			for _, v := range frame {
+				mono += v
+			}
+			for c := range frame {
+				d.buf[at+c] = feedback * d.returned[(c+channels-1)%channels]
+			}
+			d.buf[at] += mono / float64(channels)
+		} else {
+			for c, v := range frame {
+				d.buf[at+c] = v + feedback*d.returned[c]
+			}
+		}
+		for c, v := range frame {
+			frame[c] = v*(1-mix) + d.delayed[c]*mix
+		}
+		d.tail.listen(d.buf[at:at+channels], int(delay)+3)
+		d.pos = (d.pos + 1) % frames
+	})
+}
+
+// read returns channel c of the buffer at a fractional frame position, interpolating between
+// frames so that delay times between samples, and changes to them, sound smooth.
+func (d *Delay) read(c, channels int, pos float64) float64 {
+	frames := len(d.buf) / channels
+	i := int(math.Floor(pos))
+	t := pos - float64(i)
+	at := func(i int) float64 {
+		return d.buf[mod(i, frames)*channels+c]
+	}
+	// 4 point, 3rd order Hermite interpolation
+	y0, y1, y2, y3 := at(i-1), at(i), at(i+1), at(i+2)
+	c1 := (y2 - y0) / 2
+	c2 := y0 - 2.5*y1 + 2*y2 - y3/2
+	c3 := (y3-y0)/2 + 1.5*(y1-y2)
+	return ((c3*t+c2)*t+c1)*t + y1
+}
@@ -0,0 +1,117 @@
+package daw
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// impulse returns frames of silence in each of channels, but for a 1 at the start of the first.
+func impulse(channels, frames int) []float64 {
+	samples := make([]float64, channels*frames)
+	samples[0] = 1
+	return samples
+}
+
+func TestDelayEchoes(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 10*time.Millisecond, .5)
+	out := readSamples(t, d, 100)
+	for i, v := range out {
+		// the dry impulse and first echo are mixed in at half, and each echo after is half as loud
+		want := 0.
+		if i%10 == 0 {
+			want = math.Min(.5, math.Pow(.5, float64(i/10)))
+		}
+		if math.Abs(v-want) > 1e-9 {
+			t.Errorf("sample %v: got %v, want %v", i, v, want)
+		}
+	}
+}
+
+func TestDelayMix(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 10*time.Millisecond, 0)
+	d.SetMix(1)
+	out := readSamples(t, d, 20)
+	if out[0] != 0 || math.Abs(out[10]-1) > 1e-9 {
+		t.Errorf("got %v and %v, want no dry signal and a full echo", out[0], out[10])
+	}
+}
+
+func TestDelayBeats(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 0, 0)
+	d.SetBeats(Dotted(EighthNote), 120)
+	out := readSamples(t, d, 400)
+	// a dotted eighth at 120 bpm is 375ms
+	for i, v := range out[1:] {
+		want := 0.
+		if i+1 == 375 {
+			want = .5
+		}
+		if math.Abs(v-want) > 1e-9 {
+			t.Errorf("sample %v: got %v, want %v", i+1, v, want)
+		}
+	}
+}
+
+func TestDelayFractionalTime(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 10500*time.Microsecond, 0)
+	d.SetMix(1)
+	out := readSamples(t, d, 20)
+	// the echo falls evenly between two samples, and keeps its level
+	var sum float64
+	for _, v := range out {
+		sum += v
+	}
+	if math.Abs(out[10]-out[11]) > 1e-9 || out[10] <= 0 || out[10] >= 1 || math.Abs(sum-1) > 1e-9 {
+		t.Errorf("got %v", out)
+	}
+}
+
+func TestDelayPingPong(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 2, impulse(2, 1)), 10*time.Millisecond, .5)
+	d.SetMix(1)
+	d.SetPingPong(true)
+	out := readSamples(t, d, 2*40)
+	// the input enters the left channel, then each echo crosses to the other
+	want := map[int]float64{2 * 10: .5, 2*20 + 1: .25, 2 * 30: .125}
+	for i, v := range out {
+		if math.Abs(v-want[i]) > 1e-9 {
+			t.Errorf("sample %v: got %v, want %v", i, v, want[i])
+		}
+	}
+}
+
+func TestDelayFilter(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 10*time.Millisecond, 1)
+	d.SetMix(1)
+	d.SetFilter(LowPass, 20, ButterworthQ)
+	out := readSamples(t, d, 30)
+	// the first echo is heard before it is filtered; the next has lost its high frequencies
+	if math.Abs(out[10]-1) > 1e-9 {
+		t.Errorf("first echo: got %v, want 1", out[10])
+	}
+	for i, v := range out[20:] {
+		if math.Abs(v) > .1 {
+			t.Errorf("sample %v: got %v, want a filtered echo", i+20, v)
+		}
+	}
+}
+
+func TestDelayTail(t *testing.T) {
+	d := NewDelay(newSamplesReader(1000, 1, impulse(1, 1)), 10*time.Millisecond, .5)
+	// read a little at a time, as the tail only ends between reads
+	var played int
+	b := make([]byte, 8*10)
+	for played < 10000 {
+		n, err := d.ReadPCM(b)
+		played += n / 8
+		if err != nil {
+			break
+		}
+	}
+	// echoes fall below the tail's silence after 14 repeats, then the delay waits one more delay
+	// time before it ends
+	if played < 140 || played > 200 {
+		t.Errorf("played %v samples after an impulse, want the echoes to ring out then end", played)
+	}
+}
@@ -0,0 +1,150 @@
+package daw
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/oakmound/oak/v4/audio"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// A Driver selects what writers created by this package send audio to.
+type Driver int
+
+const (
+	DriverDefault Driver = iota
+	DriverPulse
+	DriverDirectSound
+	DriverALSA
+	// DriverOffline writes audio to files in OfflineDir, or discards it, instead of a sound device.
+	DriverOffline
+)
+
+var driverNames = map[Driver]string{
+	DriverDefault:     "default",
+	DriverPulse:       "pulseaudio",
+	DriverDirectSound: "directsound",
+	DriverALSA:        "alsa",
+	DriverOffline:     "offline",
+}
+
+func (d Driver) String() string {
+	return driverNames[d]
+}
+
+// ParseDriver converts a driver name, as returned by Driver.String, back into a Driver.
+func ParseDriver(name string) (Driver, error) {
+	for d, dName := range driverNames {
+		if strings.EqualFold(name, dName) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown audio driver %q", name)
+}
+
+// These environment variables are read when this package is imported. DriverEnv selects a driver
+// by name; if unset the default sound device is used, falling back to DriverOffline if there isn't one.
+// If the driver it names cannot be used, creating a writer returns why until Init is called.
+// OfflineDirEnv sets OfflineDir.
+const (
+	DriverEnv     = "DAW_DRIVER"
+	OfflineDirEnv = "DAW_OFFLINE_DIR"
+)
+
+// OfflineDir, if set, is a directory each writer created by DriverOffline will save its audio to.
+var OfflineDir string
+
+var (
+	driverMutex   sync.Mutex
+	currentDriver Driver
+	newWriter     = func(f pcm.Format) (pcm.Writer, error) {
+		return nil, fmt.Errorf("daw has not been initialized")
+	}
+)
+
+func init() {
+	OfflineDir = os.Getenv(OfflineDirEnv)
+	if name := os.Getenv(DriverEnv); name != "" {
+		d, err := ParseDriver(name)
+		if err == nil {
+			err = Init(d)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", DriverEnv, err)
+			newWriter = func(pcm.Format) (pcm.Writer, error) {
+				return nil, err
+			}
+		}
+		return
+	}
+	if err := Init(DriverDefault); err != nil {
+		Init(DriverOffline)
+	}
+}
+
+// Init sets the driver used by all writers created after it is called.
+func Init(d Driver) error {
+	driverMutex.Lock()
+	defer driverMutex.Unlock()
+	switch d {
+	case DriverOffline:
+		newWriter = newOfflineDriverWriter
+	case DriverDefault, DriverPulse, DriverDirectSound, DriverALSA:
+		if err := audio.Init(audio.Driver(d)); err != nil {
+			return err
+		}
+		newWriter = audio.NewWriter
+	default:
+		return fmt.Errorf("unknown audio driver %v", int(d))
+	}
+	currentDriver = d
+	return nil
+}
+
+// CurrentDriver returns the driver most recently passed to a successful Init.
+func CurrentDriver() Driver {
+	driverMutex.Lock()
+	defer driverMutex.Unlock()
+	return currentDriver
+}
+
+func NewFormatWriter(format pcm.Format) (Writer, error) {
+	driverMutex.Lock()
+	nw := newWriter
+	driverMutex.Unlock()
+	return nw(format)
+}
+
+func MustNewFormatWriter(format pcm.Format) Writer {
+	w, err := NewFormatWriter(format)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+var offlineWriterCount int
+
+func newOfflineDriverWriter(f pcm.Format) (pcm.Writer, error) {
+	if OfflineDir == "" {
+		// nothing will read the audio back, so keeping it would only grow without limit
+		w := NewOfflineWriter(f, io.Discard)
+		w.Realtime = true
+		return w, nil
+	}
+	driverMutex.Lock()
+	offlineWriterCount++
+	name := filepath.Join(OfflineDir, fmt.Sprintf("daw-%d.wav", offlineWriterCount))
+	driverMutex.Unlock()
+	wav, err := CreateWAV(name, f, EncodingInt)
+	if err != nil {
+		return nil, err
+	}
+	w := NewOfflineWriter(f, wav)
+	w.Realtime = true
+	return w, nil
+}
@@ -0,0 +1,49 @@
+package daw
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+func TestBadDriverEnv(t *testing.T) {
+	format := pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}
+	if os.Getenv("DAW_TEST_BAD_DRIVER") == "1" {
+		// importing the package did not panic; creating a writer should report why it can't
+		if _, err := NewFormatWriter(format); err == nil || !strings.Contains(err.Error(), DriverEnv) {
+			t.Fatalf("got error %v, want one naming %v", err, DriverEnv)
+		}
+		if err := Init(DriverOffline); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewFormatWriter(format); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestBadDriverEnv$")
+	cmd.Env = append(os.Environ(), "DAW_TEST_BAD_DRIVER=1", DriverEnv+"=no-such-driver")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+func TestOfflineDriverDiscards(t *testing.T) {
+	dir := OfflineDir
+	OfflineDir = ""
+	defer func() { OfflineDir = dir }()
+	w, err := newOfflineDriverWriter(pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.WritePCM(make([]byte, 4096)); err != nil {
+		t.Fatal(err)
+	}
+	if b := w.(*OfflineWriter).Bytes(); len(b) != 0 {
+		t.Errorf("kept %v bytes, want none", len(b))
+	}
+}
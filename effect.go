@@ -0,0 +1,103 @@
+package daw
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// An effect is embedded by readers which change another reader's audio a frame at a time, like
+// filters, delays and reverbs. It reads from the reader it embeds, in that reader's format and
+// encoding.
+type effect struct {
+	pcm.Reader
+
+	// if tail is set, the effect keeps playing after its reader ends, until the tail is over
+	tail *tail
+
+	// format is the format of the frames being processed
+	format pcm.Format
+	frame  []float64
+}
+
+func (e *effect) PCMEncoding() Encoding {
+	return EncodingOf(e.Reader)
+}
+
+// process reads from the effect's reader into b, then passes each frame read to fn to change in
+// place.
+func (e *effect) process(b []byte, fn func(frame []float64)) (n int, err error) {
+	e.format = e.PCMFormat()
+	if e.tail != nil {
+		n, err = e.tail.read(e.Reader, b, e.format)
+	} else {
+		n, err = e.Reader.ReadPCM(b)
+	}
+	processFrames(b[:n], e.format, e.PCMEncoding(), &e.frame, fn)
+	return n, err
+}
+
+// value returns the value of p for the frame being processed, smoothing it on by a frame.
+func (e *effect) value(p *Param) float64 {
+	return p.Next(e.format.SampleRate)
+}
+
+// tailSilence is how quiet an effect must be, as a sample value, before its tail is considered over.
+const tailSilence = 1e-4
+
+// A tail lets an effect keep playing after its input ends, until it falls silent.
+type tail struct {
+	ended bool
+	// quiet is how many frames in a row have been silent
+	quiet int
+	over  bool
+}
+
+// read reads from r into b. Once r has ended, it fills b with silence instead, until the effect's
+// tail is over and it returns io.EOF.
+func (t *tail) read(r pcm.Reader, b []byte, format pcm.Format) (n int, err error) {
+	if t.over {
+		return 0, io.EOF
+	}
+	if !t.ended {
+		n, err = r.ReadPCM(b)
+		if !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		t.ended = true
+	}
+	// keep any partial frame so what follows stays aligned
+	size := format.SampleSize()
+	if size == 0 {
+		return n, io.EOF
+	}
+	end := len(b) - len(b)%size
+	if end <= n {
+		return n, nil
+	}
+	silence := byte(0)
+	if format.Bits == 8 {
+		silence = 128
+	}
+	for i := n; i < end; i++ {
+		b[i] = silence
+	}
+	return end, nil
+}
+
+// listen observes one frame an effect will go on to play, and marks the tail over once its input
+// has ended and it has been silent for length frames.
+func (t *tail) listen(frame []float64, length int) {
+	for _, v := range frame {
+		if math.Abs(v) > tailSilence {
+			t.quiet = 0
+			return
+		}
+	}
+	t.quiet++
+	if t.ended && t.quiet >= length {
+		t.over = true
+	}
+}
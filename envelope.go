@@ -0,0 +1,151 @@
+package daw
+
+import (
+	"io"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// A Curve shapes how an envelope moves from one level to another.
+type Curve int
+
+const (
+	LinearCurve Curve = iota
+	// ExponentialCurve moves quickly at first and slows as it approaches its target, like an analog envelope.
+	ExponentialCurve
+)
+
+// exponentialCurveRate controls how sharply ExponentialCurve bends.
+const exponentialCurveRate = 5
+
+func (c Curve) shape(t float64) float64 {
+	if c == ExponentialCurve {
+		return (1 - math.Exp(-exponentialCurveRate*t)) / (1 - math.Exp(-exponentialCurveRate))
+	}
+	return t
+}
+
+// An ADSR describes a volume envelope: from silence a note rises to full volume over Attack, falls
+// to the Sustain level over Decay, holds there until the note is released, then falls to silence
+// over Release.
+type ADSR struct {
+	Attack  time.Duration
+	Decay   time.Duration
+	Sustain float64
+	Release time.Duration
+	Curve   Curve
+}
+
+type envelopeStage int
+
+const (
+	stageAttack envelopeStage = iota
+	stageDecay
+	stageSustain
+	stageRelease
+	stageDone
+)
+
+// An Envelope is a playing ADSR. NoteOff may be called from any goroutine; all other methods
+// should only be called by whatever is producing audio.
+type Envelope struct {
+	ADSR
+
+	stage      envelopeStage
+	level      float64
+	stageStart float64
+	stagePos   int
+	released   int32
+}
+
+// Start begins a new envelope at the start of its attack.
+func (a ADSR) Start() *Envelope {
+	return &Envelope{ADSR: a}
+}
+
+// NoteOff moves this envelope to its release stage, from whatever level it is currently at.
+func (e *Envelope) NoteOff() {
+	atomic.StoreInt32(&e.released, 1)
+}
+
+// Done reports whether this envelope has finished releasing.
+func (e *Envelope) Done() bool {
+	return e.stage == stageDone
+}
+
+func (e *Envelope) setStage(s envelopeStage) {
+	e.stage = s
+	e.stageStart = e.level
+	e.stagePos = 0
+}
+
+// Next advances this envelope by one sample at sampleRate, returning its level from 0 to 1.
+func (e *Envelope) Next(sampleRate uint32) float64 {
+	if e.stage < stageRelease && atomic.LoadInt32(&e.released) == 1 {
+		e.setStage(stageRelease)
+	}
+	for {
+		var length time.Duration
+		var target float64
+		switch e.stage {
+		case stageAttack:
+			length, target = e.Attack, 1
+		case stageDecay:
+			length, target = e.Decay, e.Sustain
+		case stageSustain:
+			e.level = e.Sustain
+			return e.level
+		case stageRelease:
+			length, target = e.Release, 0
+		case stageDone:
+			e.level = 0
+			return 0
+		}
+		samples := int(length.Seconds() * float64(sampleRate))
+		if e.stagePos >= samples {
+			e.level = target
+			e.setStage(e.stage + 1)
+			continue
+		}
+		t := float64(e.stagePos) / float64(samples)
+		e.level = e.stageStart + (target-e.stageStart)*e.Curve.shape(t)
+		e.stagePos++
+		return e.level
+	}
+}
+
+var _ pcm.Reader = &EnvelopeReader{}
+
+// An EnvelopeReader applies an Envelope to the volume of another reader. It returns io.EOF once its
+// envelope has been released and finished.
+type EnvelopeReader struct {
+	pcm.Reader
+	*Envelope
+	frame []float64
+}
+
+// Apply starts a new envelope shaping r.
+func (a ADSR) Apply(r pcm.Reader) *EnvelopeReader {
+	return &EnvelopeReader{
+		Reader:   r,
+		Envelope: a.Start(),
+	}
+}
+
+func (er *EnvelopeReader) ReadPCM(b []byte) (n int, err error) {
+	if er.Done() {
+		return 0, io.EOF
+	}
+	n, err = er.Reader.ReadPCM(b)
+	format := er.PCMFormat()
+	processFrames(b[:n], format, EncodingOf(er.Reader), &er.frame, func(frame []float64) {
+		level := er.Next(format.SampleRate)
+		for c := range frame {
+			frame[c] *= level
+		}
+	})
+	return n, err
+}
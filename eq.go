@@ -0,0 +1,126 @@
+package daw
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &EQ{}
+
+// An EQ is a parametric equalizer: it filters another reader through a chain of biquad filters,
+// or bands. Bands may be added, removed, changed, enabled and disabled from any goroutine while
+// the EQ is being read from; changes to a band's cutoff, resonance and gain are smoothed over
+// FilterSmoothing.
+type EQ struct {
+	effect
+
+	mu    sync.Mutex
+	bands []*EQBand
+}
+
+// An EQBand is one filter of an EQ. Its settings may be changed from any goroutine.
+type EQBand struct {
+	filterControls
+	mode     FilterMode
+	disabled atomic.Bool
+
+	biquad biquad
+	// off is whether this band was disabled when last read, so its filter can start afresh when it
+	// is enabled again
+	off bool
+}
+
+// NewEQ equalizes r, starting with no bands.
+func NewEQ(r pcm.Reader) *EQ {
+	return &EQ{
+		effect: effect{Reader: r},
+	}
+}
+
+// AddBand adds an enabled filter to the end of this EQ's chain, acting around cutoff hz with a
+// resonance of q and a gain in decibels.
+func (eq *EQ) AddBand(mode FilterMode, cutoff, q, gain float64) *EQBand {
+	band := &EQBand{
+		filterControls: newFilterControls(cutoff, q, gain),
+		mode:           mode,
+	}
+	eq.mu.Lock()
+	eq.bands = append(eq.bands, band)
+	eq.mu.Unlock()
+	return band
+}
+
+// RemoveBand removes band from this EQ. It is safe to remove a band more than once.
+func (eq *EQ) RemoveBand(band *EQBand) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	for i, b := range eq.bands {
+		if b == band {
+			eq.bands = append(eq.bands[:i], eq.bands[i+1:]...)
+			return
+		}
+	}
+}
+
+// Bands returns this EQ's bands in the order they are applied.
+func (eq *EQ) Bands() []*EQBand {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	return append([]*EQBand(nil), eq.bands...)
+}
+
+func (eq *EQ) ReadPCM(b []byte) (n int, err error) {
+	// don't hold the lock while processing, so changing bands never waits on it
+	bands := eq.Bands()
+	return eq.process(b, func(frame []float64) {
+		for _, band := range bands {
+			// keep a disabled band's settings moving so it resumes where it should be
+			cutoff, q, gain := band.next(eq.format.SampleRate)
+			if band.disabled.Load() {
+				band.off = true
+				continue
+			}
+			if band.off {
+				// what the filter last heard was long ago, and would click if played out now
+				band.off = false
+				band.biquad.reset()
+			}
+			band.biquad.design(band.mode, cutoff, q, gain, eq.format.SampleRate)
+			band.biquad.process(frame)
+		}
+	})
+}
+
+// Response returns how many decibels this EQ boosts or cuts hz by at sampleRate, using the
+// settings its enabled bands were most recently set to. Drawing this across frequencies draws the
+// EQ's curve.
+func (eq *EQ) Response(hz float64, sampleRate uint32) float64 {
+	var db float64
+	for _, band := range eq.Bands() {
+		if band.Enabled() {
+			db += band.Coefficients(sampleRate).Magnitude(hz, sampleRate)
+		}
+	}
+	return db
+}
+
+func (band *EQBand) Mode() FilterMode {
+	return band.mode
+}
+
+// SetEnabled turns this band on or off. A band turned back on starts filtering afresh.
+func (band *EQBand) SetEnabled(enabled bool) {
+	band.disabled.Store(!enabled)
+}
+
+func (band *EQBand) Enabled() bool {
+	return !band.disabled.Load()
+}
+
+// Coefficients returns the coefficients of this band at sampleRate with the settings it was most
+// recently set to.
+func (band *EQBand) Coefficients(sampleRate uint32) BiquadCoefficients {
+	return DesignBiquad(band.mode, band.Cutoff(), band.Resonance(), band.Gain(), sampleRate)
+}
@@ -0,0 +1,100 @@
+package daw
+
+import (
+	"io"
+	"math"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// A samplesReader reads interleaved samples as 64 bit floats, then io.EOF.
+type samplesReader struct {
+	pcm.Format
+	samples []float64
+}
+
+func newSamplesReader(sampleRate uint32, channels uint16, samples []float64) *samplesReader {
+	return &samplesReader{
+		Format:  pcm.Format{SampleRate: sampleRate, Channels: channels, Bits: 64},
+		samples: samples,
+	}
+}
+
+func (sr *samplesReader) PCMEncoding() Encoding {
+	return EncodingFloat
+}
+
+func (sr *samplesReader) ReadPCM(b []byte) (n int, err error) {
+	if len(sr.samples) == 0 {
+		return 0, io.EOF
+	}
+	for ; n+8 <= len(b) && len(sr.samples) > 0; n += 8 {
+		encodeSample(b[n:], 64, EncodingFloat, sr.samples[0])
+		sr.samples = sr.samples[1:]
+	}
+	return n, nil
+}
+
+// readSamples reads what r produces as 64 bit floats, until it ends or limit samples, a whole number
+// of frames, have been read.
+func readSamples(t *testing.T, r pcm.Reader, limit int) []float64 {
+	t.Helper()
+	var out []float64
+	buf := make([]byte, 8*clampInt(limit, 1, 4096))
+	for len(out) < limit {
+		n, err := r.ReadPCM(buf[:8*clampInt(limit-len(out), 1, 4096)])
+		for i := 0; i+8 <= n; i += 8 {
+			out = append(out, decodeSample(buf[i:], 64, EncodingFloat))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out
+}
+
+func TestEQResponse(t *testing.T) {
+	const sampleRate = 44100
+	for _, hz := range []float64{100, 1000, 5000} {
+		sine := make([]float64, sampleRate/2)
+		for i := range sine {
+			sine[i] = .25 * math.Sin(2*math.Pi*hz*float64(i)/sampleRate)
+		}
+		eq := NewEQ(newSamplesReader(sampleRate, 1, sine))
+		eq.AddBand(Peaking, 1000, 1, 6)
+		eq.AddBand(HighShelf, 4000, ButterworthQ, -6)
+		out := readSamples(t, eq, len(sine))
+		// measure after the filters have settled
+		var peak float64
+		for _, v := range out[len(out)/2:] {
+			peak = math.Max(peak, math.Abs(v))
+		}
+		got := 20 * math.Log10(peak/.25)
+		if want := eq.Response(hz, sampleRate); math.Abs(got-want) > .1 {
+			t.Errorf("%v hz: measured %.2f dB, want %.2f dB", hz, got, want)
+		}
+	}
+}
+
+func TestEQBandReenabledStartsAfresh(t *testing.T) {
+	input := make([]float64, 200)
+	input[0] = 1
+	r := newSamplesReader(44100, 1, input)
+	eq := NewEQ(r)
+	band := eq.AddBand(Peaking, 100, 10, 12)
+
+	// the impulse leaves a narrow, loud peak ringing in the band's filter
+	readSamples(t, eq, 100)
+	band.SetEnabled(false)
+	readSamples(t, eq, 50)
+	band.SetEnabled(true)
+	for i, v := range readSamples(t, eq, 50) {
+		if v != 0 {
+			t.Fatalf("sample %v after enabling the band is %v, want silence", i, v)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package daw
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"sync"
+)
+
+// fft performs an in place fast fourier transform of x, whose length must be a power of two.
+func fft(x []complex128) {
+	fftDirection(x, -1)
+}
+
+// ifft performs an in place inverse fast fourier transform of x, whose length must be a power of two.
+func ifft(x []complex128) {
+	fftDirection(x, 1)
+	n := complex(float64(len(x)), 0)
+	for i := range x {
+		x[i] /= n
+	}
+}
+
+func fftDirection(x []complex128, sign float64) {
+	n := len(x)
+	if n <= 1 {
+		return
+	}
+	// reorder into bit reversed order so butterflies can be done in place
+	shift := 64 - bits.TrailingZeros(uint(n))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	twiddles := fftTwiddles(n)
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		stride := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				a := x[start+k]
+				w := twiddles[k*stride]
+				if sign > 0 {
+					w = cmplx.Conj(w)
+				}
+				b := x[start+k+half] * w
+				x[start+k] = a + b
+				x[start+k+half] = a - b
+			}
+		}
+	}
+}
+
+// twiddleCache holds the twiddles of each transform length, so they are not recomputed for every
+// transform.
+var twiddleCache sync.Map
+
+// fftTwiddles returns the n/2 roots of unity a forward transform of length n multiplies by.
+func fftTwiddles(n int) []complex128 {
+	if tw, ok := twiddleCache.Load(n); ok {
+		return tw.([]complex128)
+	}
+	twiddles := make([]complex128, n/2)
+	for k := range twiddles {
+		twiddles[k] = cmplx.Rect(1, -2*math.Pi*float64(k)/float64(n))
+	}
+	twiddleCache.Store(n, twiddles)
+	return twiddles
+}
+
+// nextPowerOfTwo returns the smallest power of two at least n.
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// A Window is a function applied to a block of samples before analyzing its frequencies, trading
+// how precisely a frequency can be located for how much it leaks into neighboring frequencies.
+type Window int
+
+const (
+	RectangularWindow Window = iota
+	HannWindow
+	HammingWindow
+	BlackmanWindow
+)
+
+var windowNames = map[Window]string{
+	RectangularWindow: "rectangular",
+	HannWindow:        "hann",
+	HammingWindow:     "hamming",
+	BlackmanWindow:    "blackman",
+}
+
+func (w Window) String() string {
+	return windowNames[w]
+}
+
+// Coefficients returns the n values this window multiplies a block of n samples by.
+func (w Window) Coefficients(n int) []float64 {
+	c := make([]float64, n)
+	for i := range c {
+		t := 2 * math.Pi * float64(i) / float64(n-1)
+		switch w {
+		case HannWindow:
+			c[i] = 0.5 - 0.5*math.Cos(t)
+		case HammingWindow:
+			c[i] = 0.54 - 0.46*math.Cos(t)
+		case BlackmanWindow:
+			c[i] = 0.42 - 0.5*math.Cos(t) + 0.08*math.Cos(2*t)
+		default:
+			c[i] = 1
+		}
+	}
+	if n == 1 {
+		c[0] = 1
+	}
+	return c
+}
+
+// Spectrum returns the magnitude, in decibels relative to a full scale sine wave, of each frequency
+// in samples from 0 hz up to the nyquist frequency. samples are zero padded to a power of two
+// length n, and the result has n/2+1 bins; use BinFrequency to find the frequency of each. It
+// returns nil if there are no samples.
+func Spectrum(samples []float64, window Window) []float64 {
+	if len(samples) == 0 {
+		return nil
+	}
+	n := nextPowerOfTwo(len(samples))
+	coefficients := window.Coefficients(len(samples))
+	x := make([]complex128, n)
+	var gain float64
+	for i, s := range samples {
+		x[i] = complex(s*coefficients[i], 0)
+		gain += coefficients[i]
+	}
+	fft(x)
+	db := make([]float64, n/2+1)
+	for i := range db {
+		// scale such that a full scale sine wave has a magnitude of 1
+		magnitude := cmplx.Abs(x[i]) * 2 / gain
+		db[i] = Decibels(magnitude)
+	}
+	return db
+}
+
+// BinFrequency returns the frequency in hz of a bin of a spectrum made from n samples at sampleRate.
+func BinFrequency(bin, n int, sampleRate uint32) float64 {
+	return float64(bin) * float64(sampleRate) / float64(nextPowerOfTwo(n))
+}
+
+// minDecibels is the quietest level Decibels will report, rather than negative infinity.
+const minDecibels = -200
+
+// Decibels converts a linear amplitude to decibels.
+func Decibels(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return minDecibels
+	}
+	return math.Max(20*math.Log10(amplitude), minDecibels)
+}
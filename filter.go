@@ -0,0 +1,223 @@
+package daw
+
+import (
+	"math"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// A FilterMode is which frequencies a filter lets through.
+type FilterMode int
+
+const (
+	// LowPass lets frequencies below the cutoff through.
+	LowPass FilterMode = iota
+	// HighPass lets frequencies above the cutoff through.
+	HighPass
+	// BandPass lets frequencies near the cutoff through.
+	BandPass
+	// Notch lets everything but frequencies near the cutoff through.
+	Notch
+	// Peaking boosts or cuts frequencies near the cutoff by the filter's gain.
+	Peaking
+	// LowShelf boosts or cuts frequencies below the cutoff by the filter's gain.
+	LowShelf
+	// HighShelf boosts or cuts frequencies above the cutoff by the filter's gain.
+	HighShelf
+	// AllPass lets every frequency through, but delays frequencies near the cutoff.
+	AllPass
+)
+
+var filterModeNames = map[FilterMode]string{
+	LowPass:   "low pass",
+	HighPass:  "high pass",
+	BandPass:  "band pass",
+	Notch:     "notch",
+	Peaking:   "peaking",
+	LowShelf:  "low shelf",
+	HighShelf: "high shelf",
+	AllPass:   "all pass",
+}
+
+func (m FilterMode) String() string {
+	return filterModeNames[m]
+}
+
+// FilterSmoothing is how long changes to a filter's cutoff, resonance and gain take to fully apply.
+var FilterSmoothing = 10 * time.Millisecond
+
+// ButterworthQ is the resonance at which a filter is as flat as possible below its cutoff, without
+// a resonant peak.
+const ButterworthQ = 1 / math.Sqrt2
+
+// filterControls are the cutoff, resonance and gain shared by each kind of filter. They may be set
+// from any goroutine.
+type filterControls struct {
+	// If Modulation is set, it is called every sample with the filter's smoothed cutoff and
+	// resonance, and returns the cutoff and resonance to use for that sample, so that a filter
+	// may follow an LFO or envelope.
+	Modulation func(cutoff, resonance float64) (float64, float64)
+
+	cutoff    *Param
+	resonance *Param
+	gain      *Param
+}
+
+func newFilterControls(cutoff, resonance, gain float64) filterControls {
+	return filterControls{
+		cutoff:    NewParam(cutoff, FilterSmoothing),
+		resonance: NewParam(resonance, FilterSmoothing),
+		gain:      NewParam(gain, FilterSmoothing),
+	}
+}
+
+// SetCutoff sets the frequency in hz this filter acts around.
+func (fc *filterControls) SetCutoff(hz float64) {
+	fc.cutoff.Set(hz)
+}
+
+func (fc *filterControls) Cutoff() float64 {
+	return fc.cutoff.Get()
+}
+
+// SetResonance sets this filter's Q, how sharply it emphasizes frequencies near its cutoff.
+// ButterworthQ has no emphasis; higher values ring.
+func (fc *filterControls) SetResonance(q float64) {
+	fc.resonance.Set(q)
+}
+
+func (fc *filterControls) Resonance() float64 {
+	return fc.resonance.Get()
+}
+
+// SetGain sets how many decibels Peaking and shelving filters boost by, or cut by if negative.
+func (fc *filterControls) SetGain(db float64) {
+	fc.gain.Set(db)
+}
+
+func (fc *filterControls) Gain() float64 {
+	return fc.gain.Get()
+}
+
+// next returns the cutoff, resonance and gain to use for the next sample, kept within what a
+// filter at sampleRate can represent.
+func (fc *filterControls) next(sampleRate uint32) (cutoff, q, gain float64) {
+	cutoff = fc.cutoff.Next(sampleRate)
+	q = fc.resonance.Next(sampleRate)
+	gain = fc.gain.Next(sampleRate)
+	if fc.Modulation != nil {
+		cutoff, q = fc.Modulation(cutoff, q)
+	}
+	nyquist := float64(sampleRate) / 2
+	cutoff = math.Max(1, math.Min(cutoff, nyquist*.98))
+	q = math.Max(q, .025)
+	return cutoff, q, gain
+}
+
+var _ pcm.Reader = &StateVariableFilter{}
+
+// A StateVariableFilter filters another reader with a two pole state variable filter. It stays
+// stable and smooth while its cutoff and resonance change every sample, so it suits sweeps and
+// modulation. Its cutoff, resonance and gain may be set from any goroutine while it is being read
+// from.
+type StateVariableFilter struct {
+	effect
+	Mode FilterMode
+	filterControls
+
+	// state holds two integrator values for each channel
+	state []float64
+}
+
+// NewStateVariableFilter filters r with a cutoff in hz and a resonance, or Q. Use SetGain to
+// boost or cut with Peaking and shelving filters.
+func NewStateVariableFilter(r pcm.Reader, mode FilterMode, cutoff, resonance float64) *StateVariableFilter {
+	return &StateVariableFilter{
+		effect:         effect{Reader: r},
+		Mode:           mode,
+		filterControls: newFilterControls(cutoff, resonance, 0),
+	}
+}
+
+func (f *StateVariableFilter) ReadPCM(b []byte) (n int, err error) {
+	if channels := int(f.PCMFormat().Channels); len(f.state) != 2*channels {
+		f.state = make([]float64, 2*channels)
+	}
+	return f.process(b, func(frame []float64) {
+		cutoff, q, gain := f.next(f.format.SampleRate)
+		// topology preserving transform coefficients, after Andrew Simper's 'SvfLinearTrapOptimised2'
+		g := math.Tan(math.Pi * cutoff / float64(f.format.SampleRate))
+		k := 1 / q
+		a := math.Pow(10, gain/40)
+		switch f.Mode {
+		case Peaking:
+			k /= a
+		case LowShelf:
+			g /= math.Sqrt(a)
+		case HighShelf:
+			g *= math.Sqrt(a)
+		}
+		a1 := 1 / (1 + g*(g+k))
+		a2 := g * a1
+		a3 := g * a2
+		for c, v0 := range frame {
+			ic1, ic2 := &f.state[2*c], &f.state[2*c+1]
+			v3 := v0 - *ic2
+			v1 := a1**ic1 + a2*v3
+			v2 := *ic2 + a2**ic1 + a3*v3
+			*ic1 = 2*v1 - *ic1
+			*ic2 = 2*v2 - *ic2
+			switch f.Mode {
+			case LowPass:
+				frame[c] = v2
+			case HighPass:
+				frame[c] = v0 - k*v1 - v2
+			case BandPass:
+				frame[c] = k * v1
+			case Notch:
+				frame[c] = v0 - k*v1
+			case Peaking:
+				frame[c] = v0 + k*(a*a-1)*v1
+			case LowShelf:
+				frame[c] = v0 + k*(a-1)*v1 + (a*a-1)*v2
+			case HighShelf:
+				frame[c] = a*a*v0 + k*(1-a)*a*v1 + (1-a*a)*v2
+			case AllPass:
+				frame[c] = v0 - 2*k*v1
+			}
+		}
+	})
+}
+
+var _ pcm.Reader = &BiquadFilter{}
+
+// A BiquadFilter filters another reader with a two pole, two zero filter. It is cheaper than a
+// StateVariableFilter when its cutoff and resonance are steady, but may click if they change
+// quickly. Its cutoff, resonance and gain may be set from any goroutine while it is being read
+// from.
+type BiquadFilter struct {
+	effect
+	Mode FilterMode
+	filterControls
+
+	biquad biquad
+}
+
+// NewBiquadFilter filters r with a cutoff in hz and a resonance, or Q. Use SetGain to boost or
+// cut with Peaking and shelving filters.
+func NewBiquadFilter(r pcm.Reader, mode FilterMode, cutoff, resonance float64) *BiquadFilter {
+	return &BiquadFilter{
+		effect:         effect{Reader: r},
+		Mode:           mode,
+		filterControls: newFilterControls(cutoff, resonance, 0),
+	}
+}
+
+func (f *BiquadFilter) ReadPCM(b []byte) (n int, err error) {
+	return f.process(b, func(frame []float64) {
+		cutoff, q, gain := f.next(f.format.SampleRate)
+		f.biquad.design(f.Mode, cutoff, q, gain, f.format.SampleRate)
+		f.biquad.process(frame)
+	})
+}
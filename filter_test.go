@@ -0,0 +1,100 @@
+package daw
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// stereoSine returns half a second of a sine at hz in the left channel, with a silent right channel.
+func stereoSine(sampleRate uint32, hz float64) []float64 {
+	samples := make([]float64, sampleRate)
+	for i := 0; i < len(samples); i += 2 {
+		samples[i] = .25 * math.Sin(2*math.Pi*hz*float64(i/2)/float64(sampleRate))
+	}
+	return samples
+}
+
+// sineGain returns how many decibels a filter, made by newFilter, changes the level of a sine at hz,
+// measured once the filter has settled.
+func sineGain(t *testing.T, hz float64, newFilter func(r pcm.Reader) pcm.Reader) float64 {
+	t.Helper()
+	const sampleRate = 44100
+	in := stereoSine(sampleRate, hz)
+	out := readSamples(t, newFilter(newSamplesReader(sampleRate, 2, append([]float64{}, in...))), len(in))
+	var inPower, outPower float64
+	for i := len(in) / 2; i < len(in); i += 2 {
+		inPower += in[i] * in[i]
+		outPower += out[i] * out[i]
+		if out[i+1] != 0 {
+			t.Fatalf("the silent channel has %v at sample %v", out[i+1], i+1)
+		}
+	}
+	return 10 * math.Log10(outPower/inPower)
+}
+
+func TestFilterResponse(t *testing.T) {
+	const (
+		sampleRate = 44100
+		cutoff     = 1000
+		q          = 2
+		gain       = 6
+	)
+	modes := []FilterMode{LowPass, HighPass, BandPass, Notch, Peaking, LowShelf, HighShelf, AllPass}
+	filters := map[string]func(mode FilterMode) func(r pcm.Reader) pcm.Reader{
+		"state variable": func(mode FilterMode) func(r pcm.Reader) pcm.Reader {
+			return func(r pcm.Reader) pcm.Reader {
+				f := NewStateVariableFilter(r, mode, cutoff, q)
+				f.SetGain(gain)
+				return f
+			}
+		},
+		"biquad": func(mode FilterMode) func(r pcm.Reader) pcm.Reader {
+			return func(r pcm.Reader) pcm.Reader {
+				f := NewBiquadFilter(r, mode, cutoff, q)
+				f.SetGain(gain)
+				return f
+			}
+		},
+	}
+	for name, newFilter := range filters {
+		for _, mode := range modes {
+			design := DesignBiquad(mode, cutoff, q, gain, sampleRate)
+			for _, hz := range []float64{200, 800, 3000} {
+				got := sineGain(t, hz, newFilter(mode))
+				if want := design.Magnitude(hz, sampleRate); math.Abs(got-want) > .1 {
+					t.Errorf("%v %v at %v hz: measured %.2f dB, want %.2f dB", name, mode, hz, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestFilterModulation(t *testing.T) {
+	const sampleRate = 44100
+	design := DesignBiquad(LowPass, 4000, ButterworthQ, 0, sampleRate)
+	got := sineGain(t, 3000, func(r pcm.Reader) pcm.Reader {
+		f := NewStateVariableFilter(r, LowPass, 100, 10)
+		f.Modulation = func(cutoff, resonance float64) (float64, float64) {
+			return cutoff * 40, ButterworthQ
+		}
+		return f
+	})
+	if want := design.Magnitude(3000, sampleRate); math.Abs(got-want) > .1 {
+		t.Errorf("measured %.2f dB, want %.2f dB", got, want)
+	}
+}
+
+func TestFilterCutoffAboveNyquist(t *testing.T) {
+	for _, r := range []pcm.Reader{
+		NewStateVariableFilter(newSamplesReader(44100, 2, stereoSine(44100, 1000)), HighPass, 100000, 0),
+		NewBiquadFilter(newSamplesReader(44100, 2, stereoSine(44100, 1000)), HighPass, 100000, 0),
+	} {
+		for i, v := range readSamples(t, r, 44100) {
+			if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > 1 {
+				t.Fatalf("%T: sample %v is %v", r, i, v)
+			}
+		}
+	}
+}
@@ -1,17 +1,46 @@
 package daw
 
 import (
-	"github.com/oakmound/oak/v4/audio"
 	"github.com/oakmound/oak/v4/audio/pcm"
 )
 
+// DefaultFormat is the format used by NewWriter. Sound device writers expect EncodingInt samples.
 var DefaultFormat = pcm.Format{
 	SampleRate: 44100,
 	Channels:   2,
-	// Bits does not reflect that some writers expect floats, and some expect ints.
-	Bits: 32,
+	Bits:       32,
 }
 
 func NewWriter() Writer {
-	return audio.MustNewWriter(DefaultFormat)
+	return MustNewFormatWriter(DefaultFormat)
+}
+
+// An Encoding describes how each sample of a pcm.Format is represented, which pcm.Format
+// itself does not.
+type Encoding uint8
+
+const (
+	// EncodingInt samples are signed little endian integers, except 8 bit samples, which are unsigned.
+	EncodingInt Encoding = iota
+	// EncodingFloat samples are little endian IEEE floats from -1 to 1.
+	EncodingFloat
+)
+
+// PCMEncoding returns this encoding. Types can embed an Encoding to implement Encoded.
+func (e Encoding) PCMEncoding() Encoding {
+	return e
+}
+
+// An Encoded type declares the Encoding of the samples it reads or writes. Types which are not
+// Encoded are assumed to use EncodingInt.
+type Encoded interface {
+	PCMEncoding() Encoding
+}
+
+// EncodingOf returns the Encoding of f's samples.
+func EncodingOf(f pcm.Formatted) Encoding {
+	if enc, ok := f.(Encoded); ok {
+		return enc.PCMEncoding()
+	}
+	return EncodingInt
 }
@@ -1,8 +1,16 @@
 package daw
 
+import "math"
+
 type Key struct {
 	Start   Pitch
 	Pattern KeyPattern
+	// Descending, if set, is the pattern this key's scale follows on the way down, listed from
+	// the bottom up like Pattern. Otherwise the scale descends as it ascends.
+	Descending KeyPattern
+	// Tuning decides the frequencies of the steps of Pattern. If it is nil, the key is in
+	// twelve tone equal temperament.
+	Tuning Tuning
 }
 
 type KeyPattern []Step
@@ -27,7 +35,87 @@ var MinorKey KeyPattern = []Step{
 	WholeStep,
 }
 
-// Note also: Harmonic minor, melodic minor (and usage when descending / ascending)
+// The church modes. Each is the major scale started from a different degree.
+var (
+	IonianMode     = MajorKey
+	DorianMode     = KeyPattern{WholeStep, HalfStep, WholeStep, WholeStep, WholeStep, HalfStep, WholeStep}
+	PhrygianMode   = KeyPattern{HalfStep, WholeStep, WholeStep, WholeStep, HalfStep, WholeStep, WholeStep}
+	LydianMode     = KeyPattern{WholeStep, WholeStep, WholeStep, HalfStep, WholeStep, WholeStep, HalfStep}
+	MixolydianMode = KeyPattern{WholeStep, WholeStep, HalfStep, WholeStep, WholeStep, HalfStep, WholeStep}
+	AeolianMode    = MinorKey
+	LocrianMode    = KeyPattern{HalfStep, WholeStep, WholeStep, HalfStep, WholeStep, WholeStep, WholeStep}
+)
+
+var (
+	HarmonicMinorKey = KeyPattern{WholeStep, HalfStep, WholeStep, WholeStep, HalfStep, Minor3, HalfStep}
+	// MelodicMinorKey is the ascending form of the melodic minor scale. Traditionally it descends
+	// as the natural minor scale; see MelodicMinor.
+	MelodicMinorKey = KeyPattern{WholeStep, HalfStep, WholeStep, WholeStep, WholeStep, WholeStep, HalfStep}
+
+	MajorPentatonicKey = KeyPattern{WholeStep, WholeStep, Minor3, WholeStep, Minor3}
+	MinorPentatonicKey = KeyPattern{Minor3, WholeStep, WholeStep, Minor3, WholeStep}
+	BluesKey           = KeyPattern{Minor3, WholeStep, HalfStep, HalfStep, Minor3, WholeStep}
+	MajorBluesKey      = KeyPattern{WholeStep, HalfStep, HalfStep, Minor3, WholeStep, Minor3}
+	WholeToneKey       = KeyPattern{WholeStep, WholeStep, WholeStep, WholeStep, WholeStep, WholeStep}
+	// DiminishedKey alternates whole and half steps. Starting with a half step instead gives
+	// DominantDiminishedKey.
+	DiminishedKey         = KeyPattern{WholeStep, HalfStep, WholeStep, HalfStep, WholeStep, HalfStep, WholeStep, HalfStep}
+	DominantDiminishedKey = KeyPattern{HalfStep, WholeStep, HalfStep, WholeStep, HalfStep, WholeStep, HalfStep, WholeStep}
+	ChromaticKey          = KeyPattern{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+)
+
+// Scales from outside of western music which fit twelve tone equal temperament.
+var (
+	// Hindustani thaats; Bilaval, Kafi, Asavari, Bhairavi and Kalyan are the Ionian, Dorian,
+	// Aeolian, Phrygian and Lydian modes.
+	BhairavThaat = KeyPattern{HalfStep, Minor3, HalfStep, WholeStep, HalfStep, Minor3, HalfStep}
+	KhamajThaat  = MixolydianMode
+	MarwaThaat   = KeyPattern{HalfStep, Minor3, WholeStep, HalfStep, WholeStep, WholeStep, HalfStep}
+	PurviThaat   = KeyPattern{HalfStep, Minor3, WholeStep, HalfStep, HalfStep, Minor3, HalfStep}
+	TodiThaat    = KeyPattern{HalfStep, WholeStep, Minor3, HalfStep, HalfStep, Minor3, HalfStep}
+
+	// Japanese pentatonic scales.
+	HirajoshiKey = KeyPattern{WholeStep, HalfStep, Major3, HalfStep, Major3}
+	InKey        = KeyPattern{HalfStep, Major3, WholeStep, HalfStep, Major3}
+
+	// MaqamHijazKey approximates maqam Hijaz without quarter tones.
+	MaqamHijazKey = KeyPattern{HalfStep, Minor3, HalfStep, WholeStep, HalfStep, WholeStep, WholeStep}
+)
+
+// QuarterTones divides the octave into 24 steps, approximating the intervals of Arabic maqamat.
+const QuarterTones EDO = 24
+
+// Maqam patterns count quarter tones, and must be used in a Key with a Tuning of QuarterTones.
+var (
+	MaqamRastKey     = KeyPattern{4, 3, 3, 4, 4, 3, 3}
+	MaqamBayatiKey   = KeyPattern{3, 3, 4, 4, 2, 4, 4}
+	MaqamSabaKey     = KeyPattern{3, 3, 2, 6, 2, 4, 4}
+	MaqamSikahKey    = KeyPattern{3, 4, 4, 3, 3, 4, 3}
+	MaqamNahawandKey = KeyPattern{4, 2, 4, 4, 2, 6, 2}
+)
+
+// Gamelan scales are not tuned consistently between ensembles; these approximate them with
+// equal divisions of the octave.
+const (
+	SlendroTuning EDO = 5
+	PelogTuning   EDO = 9
+)
+
+var (
+	// SlendroKey must be used in a Key with a Tuning of SlendroTuning.
+	SlendroKey = KeyPattern{1, 1, 1, 1, 1}
+	// PelogKey must be used in a Key with a Tuning of PelogTuning.
+	PelogKey = KeyPattern{1, 1, 2, 1, 1, 1, 2}
+)
+
+// MelodicMinor returns a melodic minor key, which ascends as MelodicMinorKey and descends as MinorKey.
+func MelodicMinor(start Pitch) Key {
+	return Key{
+		Start:      start,
+		Pattern:    MelodicMinorKey,
+		Descending: MinorKey,
+	}
+}
 
 var C5Major = Key{
 	Start:   C5,
@@ -36,10 +124,126 @@ var C5Major = Key{
 
 func (k Key) Scale() []Pitch {
 	ps := []Pitch{k.Start}
-	next := k.Start
+	var steps Step
 	for _, s := range k.Pattern {
-		next = next.Up(s)
-		ps = append(ps, next)
+		steps += s
+		ps = append(ps, k.Start.Tuned(k.Tuning, steps))
+	}
+	return ps
+}
+
+// DescendingScale returns the pitches of this key from an octave above its start down to its
+// start, following Descending if it is set.
+func (k Key) DescendingScale() []Pitch {
+	pattern := k.Pattern
+	if k.Descending != nil {
+		pattern = k.Descending
+	}
+	var steps Step
+	for _, s := range pattern {
+		steps += s
+	}
+	ps := []Pitch{k.Start.Tuned(k.Tuning, steps)}
+	for i := len(pattern) - 1; i >= 0; i-- {
+		steps -= pattern[i]
+		ps = append(ps, k.Start.Tuned(k.Tuning, steps))
 	}
 	return ps
 }
+
+// Degree returns the pitch of a degree of this key's scale, where 1 is its start. Degrees past
+// the end of the scale continue into higher octaves, and degrees below 1 into lower octaves.
+func (k Key) Degree(degree int) Pitch {
+	return k.Start.Tuned(k.Tuning, k.degreeSteps(degree))
+}
+
+func (k Key) degreeSteps(degree int) Step {
+	n := len(k.Pattern)
+	if n == 0 {
+		return 0
+	}
+	var octave Step
+	for _, s := range k.Pattern {
+		octave += s
+	}
+	octaves := floorDiv(degree-1, n)
+	steps := Step(octaves) * octave
+	for _, s := range k.Pattern[:degree-1-octaves*n] {
+		steps += s
+	}
+	return steps
+}
+
+// DegreeOf returns the degree of this key's scale p is, from 1 to the length of its pattern, in
+// any octave. It returns false if p is not within a few cents of any degree.
+func (k Key) DegreeOf(p Pitch) (int, bool) {
+	const tolerance = 5
+	for degree := 1; degree <= len(k.Pattern); degree++ {
+		cents := p.CentsFrom(k.Degree(degree))
+		octaves := math.Round(cents / 1200)
+		if math.Abs(cents-octaves*1200) < tolerance {
+			return degree, true
+		}
+	}
+	return 0, false
+}
+
+// Triad returns the chord of three pitches built in thirds from a degree of this key's scale,
+// where 1 is its start, using only pitches from the scale.
+func (k Key) Triad(degree int) []Pitch {
+	return k.Stack(degree, 3)
+}
+
+// Seventh returns the seventh chord built in thirds from a degree of this key's scale.
+func (k Key) Seventh(degree int) []Pitch {
+	return k.Stack(degree, 4)
+}
+
+// Stack returns a chord of count pitches from this key's scale, each a third above the last,
+// starting from degree.
+func (k Key) Stack(degree, count int) []Pitch {
+	ps := make([]Pitch, count)
+	for i := range ps {
+		ps[i] = k.Degree(degree + 2*i)
+	}
+	return ps
+}
+
+// Signature returns the key signature of this key as a count of sharps (positive) or flats
+// (negative), and whether it is a minor key. It is only defined for major and minor keys.
+func (k Key) Signature() (accidentals int, minor bool, ok bool) {
+	tonic := MIDINote(k.Start)
+	switch {
+	case patternEqual(k.Pattern, MajorKey):
+	case patternEqual(k.Pattern, MinorKey):
+		minor = true
+		// use the relative major
+		tonic += int(Minor3)
+	default:
+		return 0, false, false
+	}
+	// walk the circle of fifths from C
+	tonic = (tonic%12 + 12) % 12
+	for accidentals = 0; accidentals < 12; accidentals++ {
+		if (accidentals*int(Perfect5))%12 == tonic {
+			break
+		}
+	}
+	// prefer flats over more than six sharps, e.g. D flat major over C sharp major
+	if accidentals > 6 {
+		accidentals -= 12
+	}
+	return accidentals, minor, true
+}
+
+func patternEqual(a, b KeyPattern) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -20,8 +20,10 @@ func main() {
 		Format: format,
 		Pitch:  pitch,
 		Volume: 0.40,
+		// ease volume changes in to avoid clicks
+		Smoothing: 50 * time.Millisecond,
 		WaveFunc: func(pr *daw.PitchReader) float64 {
-			f := math.Sin(daw.ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate))
+			f := math.Sin(pr.Phase)
 			return f * pr.Volume
 		},
 	}
@@ -29,45 +31,51 @@ func main() {
 	ch := make(chan daw.Writer)
 	go func() {
 		w := <-ch
-		go daw.Loop(w, pr)
+		go daw.PlayTo(w, pr)
 		time.Sleep(10 * time.Second)
 	}()
 
 	go func() {
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
+			var waveFunc func(*daw.PitchReader) float64
 			switch scanner.Text() {
 			case "up":
-				*pitch = (*pitch).Up(synth.HalfStep)
+				pr.Control(func(pr *daw.PitchReader) {
+					*pr.Pitch = (*pr.Pitch).Up(synth.HalfStep)
+				})
 			case "down":
-				*pitch = (*pitch).Down(synth.HalfStep)
+				pr.Control(func(pr *daw.PitchReader) {
+					*pr.Pitch = (*pr.Pitch).Down(synth.HalfStep)
+				})
+			case "louder":
+				pr.Control(func(pr *daw.PitchReader) {
+					pr.Volume = math.Min(pr.Volume+.1, 1)
+				})
+			case "quieter":
+				pr.Control(func(pr *daw.PitchReader) {
+					pr.Volume = math.Max(pr.Volume-.1, 0)
+				})
 			case "triangle":
-				pr.WaveFunc = func(pr *daw.PitchReader) float64 {
-					p := daw.ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate)
-					m := p * (2 * pr.Volume / math.Pi)
-					if math.Sin(p) > 0 {
-						return -pr.Volume + m
-					}
-					return 3*pr.Volume - m
-				}
+				waveFunc = daw.TriangleFunc
 			case "square":
 				// pulse with ratio of 2
-				pr.WaveFunc = func(pr *daw.PitchReader) float64 {
-					if math.Sin(daw.Phase(*pr.Pitch, pr.Phase, pr.SampleRate)) > 0 {
-						return pr.Volume
-					}
-					return -pr.Volume
-				}
+				waveFunc = daw.SquareFunc
 			case "saw":
-				pr.WaveFunc = func(pr *daw.PitchReader) float64 {
-					p := daw.ModPhase(*pr.Pitch, pr.Phase, pr.SampleRate)
-					return pr.Volume - (pr.Volume / math.Pi * p)
-				}
+				waveFunc = daw.SawFunc
 			case "sin":
-				pr.WaveFunc = func(pr *daw.PitchReader) float64 {
-					f := math.Sin(daw.ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate))
-					return f * pr.Volume
-				}
+				waveFunc = daw.SinFunc
+			case "smooth triangle":
+				waveFunc = daw.BandLimitedTriangleFunc
+			case "smooth square":
+				waveFunc = daw.BandLimitedSquareFunc
+			case "smooth saw":
+				waveFunc = daw.BandLimitedSawFunc
+			}
+			if waveFunc != nil {
+				pr.Control(func(pr *daw.PitchReader) {
+					pr.WaveFunc = waveFunc
+				})
 			}
 		}
 	}()
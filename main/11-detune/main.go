@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/200sc/daw"
-	"github.com/oakmound/oak/v4/audio/synth"
 )
 
 func main() {
@@ -19,23 +18,19 @@ func main() {
 		Pitch:  pitch,
 		Volume: 0.50,
 		WaveFunc: func(pr *daw.PitchReader) float64 {
-			f := math.Sin(daw.ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate))
+			f := math.Sin(pr.Phase)
 			return f * pr.Volume
 		},
 	}
-	// detune down 20%
-	pitch2 := *pitch
-	halfDown := pitch2.Down(synth.HalfStep)
-	rawDelta := float64(int16(pitch2) - int16(halfDown))
-	delta := rawDelta * .05 // .05, .1, .2
-	pitch2 = daw.Pitch(float64(pitch2) + delta)
+	// detune up 5 cents
+	pitch2 := (*pitch).Cents(5) // 5, 10, 20
 
 	pr2 := &daw.PitchReader{
 		Format: format,
 		Pitch:  &pitch2,
 		Volume: 0.50,
 		WaveFunc: func(pr *daw.PitchReader) float64 {
-			f := math.Sin(daw.ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate))
+			f := math.Sin(pr.Phase)
 			return f * pr.Volume
 		},
 	}
@@ -15,6 +15,7 @@ func main() {
 		daw.G5,
 	}
 	// pitches := daw.MinorMajorSeventh.WithRoot(daw.C5)
+	mixer := daw.NewMixer(format)
 	for _, pitch := range pitches {
 		pitch := pitch
 		pr := &daw.PitchReader{
@@ -23,8 +24,9 @@ func main() {
 			Volume:   0.50,
 			WaveFunc: daw.SinFunc,
 		}
-		w := daw.NewWriter()
-		go daw.Loop(w, pr)
+		mixer.Add(pr).SetGain(1 / float64(len(pitches)))
 	}
+	w := daw.NewWriter()
+	go daw.PlayTo(w, mixer)
 	time.Sleep(5 * time.Second)
 }
@@ -7,125 +7,110 @@ import (
 	"github.com/200sc/daw"
 )
 
-const beatsPerMinute = 116
-
 const (
-	sixteenthNote = 1
-	eighthNote    = 2
-	quarterNote   = 4
-	halfNote      = 8
-	wholeNote     = 16
+	sixteenthNote = daw.SixteenthNote
+	eighthNote    = daw.EighthNote
+	quarterNote   = daw.QuarterNote
+	wholeNote     = daw.WholeNote
 )
 
-// assuming 4/4
-// quarterNote * beatsPerMinute = time.Minute
-const quarterInterval = time.Minute / beatsPerMinute
-const sixteenthInterval = quarterInterval / 4
-
-func beatToDuration(beat int) time.Duration {
-	return sixteenthInterval * time.Duration(beat)
+// composer places each chord right after the one before it.
+type composer struct {
+	daw.Track
+	beat float64
 }
 
-type Note struct {
-	Pitch    daw.Pitch
-	Duration time.Duration
+func (c *composer) chord(root daw.Pitch, chord daw.Chord, beats float64) {
+	c.Add(chord.Notes(root, c.beat, beats)...)
+	c.beat += beats
 }
 
-func rest(beats int) []Note {
-	return []Note{
-		{
-			// No pitch == rest
-			Duration: beatToDuration(beats),
-		},
+// symbol plays a lead sheet chord symbol, with its bass in octave.
+func (c *composer) symbol(symbol string, octave int, beats float64) {
+	cs, err := daw.ParseChord(symbol)
+	if err != nil {
+		panic(err)
+	}
+	for _, p := range cs.Pitches(octave) {
+		c.Add(daw.Note{Pitch: p, Start: c.beat, Duration: beats})
 	}
+	c.beat += beats
 }
 
-func chordNotes(root daw.Pitch, chord daw.Chord, beats int) []Note {
-	pitches := chord.WithRoot(root)
-	notes := make([]Note, len(pitches))
-	for i, p := range pitches {
-		notes[i] = Note{
-			Pitch:    p,
-			Duration: beatToDuration(beats),
-		}
-	}
-	return notes
+func (c *composer) rest(beats float64) {
+	c.beat += beats
 }
 
 func main() {
 	format := daw.DefaultFormat
 
-	notes := [][]Note{
-		// Measure
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote), // dotted eighth
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote),
-		chordNotes(daw.A5, daw.MajorTriad, quarterNote),
-		rest(eighthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote),
-
-		// Measure
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.B5, daw.MinorTriad, eighthNote),
-		chordNotes(daw.B5, daw.MinorTriad, quarterNote),
-		chordNotes(daw.A5, daw.MajorTriad, quarterNote),
-
-		// Measure
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote),
-		chordNotes(daw.A5, daw.MajorTriad, quarterNote),
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote),
-		chordNotes(daw.D5, daw.MajorTriad, eighthNote+wholeNote), // key I chord
-
-		// Measure
-
-		// (Measure in whole note above)
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote),
-		chordNotes(daw.A5, daw.MajorTriad, quarterNote),
-		rest(eighthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote),
-
-		// Measure
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.D6, daw.MajorTriad, eighthNote),
-		chordNotes(daw.A5, daw.Chord{daw.Perfect4, daw.Major6}, quarterNote),
-		chordNotes(daw.D6, daw.MajorTriad, quarterNote),
-
-		// Measure
-		chordNotes(daw.C6s, daw.Chord{daw.Minor3, daw.Minor6}, eighthNote+sixteenthNote),
-		chordNotes(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote),
-		chordNotes(daw.G5, daw.MajorTriad, eighthNote),
-		chordNotes(daw.G5, daw.MajorTriad, quarterNote),
-		chordNotes(daw.C5s, daw.Chord{daw.Minor3, daw.Minor6}, quarterNote),
-
-		// Measure
-		chordNotes(daw.D5s, daw.Chord{daw.Minor3, daw.Minor6}, wholeNote),
+	c := &composer{}
+	// Measure
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote) // dotted eighth
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote)
+	c.chord(daw.A5, daw.MajorTriad, quarterNote)
+	c.rest(eighthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote)
+
+	// Measure
+	c.chord(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.B5, daw.MinorTriad, eighthNote)
+	c.chord(daw.B5, daw.MinorTriad, quarterNote)
+	c.chord(daw.A5, daw.MajorTriad, quarterNote)
+
+	// Measure
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote)
+	c.chord(daw.A5, daw.MajorTriad, quarterNote)
+	c.chord(daw.G5, daw.MajorTriad, eighthNote)
+	c.chord(daw.D5, daw.MajorTriad, eighthNote+wholeNote) // key I chord
+
+	// Measure
+
+	// (Measure in whole note above)
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.G5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote)
+	c.chord(daw.A5, daw.MajorTriad, quarterNote)
+	c.rest(eighthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote)
+
+	// Measure
+	c.chord(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.D6, daw.MajorTriad, eighthNote)
+	c.symbol("D/A", 5, quarterNote)
+	c.chord(daw.D6, daw.MajorTriad, quarterNote)
+
+	// Measure
+	c.symbol("A/C#", 6, eighthNote+sixteenthNote)
+	c.chord(daw.A5, daw.MajorTriad, eighthNote+sixteenthNote)
+	c.chord(daw.G5, daw.MajorTriad, eighthNote)
+	c.chord(daw.G5, daw.MajorTriad, quarterNote)
+	c.symbol("A/C#", 5, quarterNote)
+
+	// Measure
+	c.symbol("B/D#", 5, wholeNote)
+
+	song := daw.Song{
+		BPM:           116,
+		TimeSignature: daw.CommonTime,
+		Tracks:        []daw.Track{c.Track},
 	}
-
-	for _, ns := range notes {
-		// assumption; all notes within a chord have same duration
-		ctx, cancel := context.WithTimeout(context.Background(), ns[0].Duration-10*time.Millisecond)
-		defer cancel()
-		for _, n := range ns {
-			pitch := n.Pitch
-			if pitch == 0 {
-				continue
-			}
-			pr := &daw.PitchReader{
-				Format:   format,
-				Pitch:    &pitch,
-				Volume:   .50,
-				WaveFunc: daw.SinFunc,
-			}
-			w := daw.NewWriter()
-			go daw.LoopContext(ctx, w, pr)
-		}
-		time.Sleep(ns[0].Duration)
+	seq := daw.NewSequencer(format, song)
+	seq.Volume = .25
+	seq.Envelope = &daw.ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   80 * time.Millisecond,
+		Sustain: .7,
+		Release: 60 * time.Millisecond,
+		Curve:   daw.ExponentialCurve,
 	}
-	time.Sleep(1 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), song.Duration()+time.Second)
+	defer cancel()
+	daw.Play(ctx, seq)
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/200sc/daw"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// This compares how much aliasing each naive wave produces with its band limited version.
+// Aliasing is measured as the energy above half the nyquist frequency at frequencies which are not
+// harmonics of the pitch being played, relative to the energy of the whole wave. aliasing_test.go
+// checks the same measurement.
+
+const samples = 32768
+
+func main() {
+	format := pcm.Format{
+		SampleRate: 44100,
+		Channels:   1,
+		Bits:       32,
+	}
+	waves := []struct {
+		name         string
+		naive, bandl func(*daw.PitchReader) float64
+	}{
+		{"saw", daw.SawFunc, daw.BandLimitedSawFunc},
+		{"square", daw.SquareFunc, daw.BandLimitedSquareFunc},
+		{"triangle", daw.TriangleFunc, daw.BandLimitedTriangleFunc},
+	}
+	pitches := []daw.Pitch{daw.A3, daw.A4, daw.A5, daw.C6, daw.A6, daw.C7, daw.A7, daw.C8}
+
+	fmt.Printf("%-10s %6s %12s %12s\n", "wave", "hz", "naive dB", "limited dB")
+	for _, w := range waves {
+		for _, p := range pitches {
+			fmt.Printf("%-10s %6d %12.1f %12.1f\n", w.name, int(p),
+				aliasing(format, p, w.naive), aliasing(format, p, w.bandl))
+		}
+	}
+}
+
+func aliasing(format pcm.Format, pitch daw.Pitch, waveFunc func(*daw.PitchReader) float64) float64 {
+	pr := &daw.PitchReader{
+		Format:   format,
+		Pitch:    &pitch,
+		Volume:   1,
+		WaveFunc: waveFunc,
+	}
+	wave := make([]float64, samples)
+	for i := range wave {
+		wave[i] = pr.Next()
+	}
+	spectrum := daw.Spectrum(wave, daw.BlackmanWindow)
+
+	// the blackman window spreads each frequency across a few bins
+	const tolerance = 4
+	binWidth := daw.BinFrequency(1, samples, format.SampleRate)
+	halfNyquist := float64(format.SampleRate) / 4
+	var total, aliased float64
+	for bin, db := range spectrum {
+		power := math.Pow(10, db/10)
+		total += power
+		freq := float64(bin) * binWidth
+		harmonic := math.Round(freq / float64(pitch))
+		if freq > halfNyquist && math.Abs(freq-harmonic*float64(pitch)) > tolerance*binWidth {
+			aliased += power
+		}
+	}
+	return 10 * math.Log10(aliased/total)
+}
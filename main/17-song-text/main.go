@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/200sc/daw"
+)
+
+// This plays a song written as text, by default the song from 14-song-2. Pass another file to
+// play it instead; nothing needs to be recompiled to change the song.
+
+//go:embed song.txt
+var songText string
+
+func main() {
+	song, err := daw.ParseSong(songText)
+	if len(os.Args) > 1 {
+		song, err = daw.LoadSong(os.Args[1])
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	seq := daw.NewSequencer(daw.DefaultFormat, song)
+	seq.Volume = .25
+	seq.Envelope = &daw.ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   80 * time.Millisecond,
+		Sustain: .7,
+		Release: 60 * time.Millisecond,
+		Curve:   daw.ExponentialCurve,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), song.Duration()+time.Second)
+	defer cancel()
+	daw.Play(ctx, seq)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/200sc/daw"
+)
+
+func main() {
+	format := daw.DefaultFormat
+
+	pitch := daw.A2
+	pr := &daw.PitchReader{
+		Format:   format,
+		Pitch:    &pitch,
+		Volume:   0.25,
+		WaveFunc: daw.BandLimitedSawFunc,
+	}
+	filter := daw.NewStateVariableFilter(pr, daw.LowPass, 800, 4)
+	// sweep the cutoff up and down from the one it was set to, twice every three seconds
+	lfo := 0
+	filter.Modulation = func(cutoff, resonance float64) (float64, float64) {
+		lfo++
+		sweep := math.Sin(2 * math.Pi * float64(lfo) / float64(format.SampleRate) * 2 / 3)
+		return cutoff * math.Exp2(2*sweep), resonance
+	}
+
+	ch := make(chan daw.Writer)
+	go func() {
+		w := <-ch
+		go daw.PlayTo(w, filter)
+		time.Sleep(10 * time.Second)
+	}()
+	daw.VisualWriter(format, ch)
+}
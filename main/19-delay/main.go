@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/200sc/daw"
+)
+
+const melody = `
+tempo: 100
+key: E minor
+E5/8 r G5 r B5/4 r/8 A5 |
+G5/8 r F#5 r E5/4 r/4 |
+`
+
+func main() {
+	song, err := daw.ParseSong(melody)
+	if err != nil {
+		panic(err)
+	}
+	seq := daw.NewSequencer(daw.DefaultFormat, song)
+	seq.Volume = .25
+	seq.Envelope = &daw.ADSR{
+		Attack:  5 * time.Millisecond,
+		Decay:   150 * time.Millisecond,
+		Sustain: .3,
+		Release: 80 * time.Millisecond,
+		Curve:   daw.ExponentialCurve,
+	}
+
+	// echo every dotted eighth note, bouncing between the left and right, growing darker
+	delay := daw.NewDelay(seq, daw.BeatDuration(daw.Dotted(daw.EighthNote), song.BPM), .55)
+	delay.SetPingPong(true)
+	delay.SetMix(.4)
+	delay.SetFilter(daw.LowPass, 2500, daw.ButterworthQ)
+
+	ctx, cancel := context.WithTimeout(context.Background(), song.Duration()+5*time.Second)
+	defer cancel()
+	daw.Play(ctx, delay)
+}
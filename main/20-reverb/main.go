@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/200sc/daw"
+)
+
+// This plays the song from 17-song-text, in a large room.
+
+// song.txt is a copy of the song from 17-song-text.
+//
+//go:embed song.txt
+var songText string
+
+func main() {
+	song, err := daw.ParseSong(songText)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	seq := daw.NewSequencer(daw.DefaultFormat, song)
+	seq.Volume = .25
+	seq.Envelope = &daw.ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   80 * time.Millisecond,
+		Sustain: .7,
+		Release: 60 * time.Millisecond,
+		Curve:   daw.ExponentialCurve,
+	}
+
+	reverb := daw.NewReverb(seq)
+	reverb.SetRoomSize(.8)
+	reverb.SetDamping(.4)
+	reverb.SetPreDelay(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), song.Duration()+5*time.Second)
+	defer cancel()
+	daw.Play(ctx, reverb)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/200sc/daw"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// This plays the song from 17-song-text convolved with an impulse response. Pass a WAV file of a
+// recorded impulse response to hear the song in that room; otherwise a made up one is written to
+// a temporary file and used.
+
+// song.txt is a copy of the song from 17-song-text.
+//
+//go:embed song.txt
+var songText string
+
+func main() {
+	name := ""
+	if len(os.Args) > 1 {
+		name = os.Args[1]
+	} else {
+		name = filepath.Join(os.TempDir(), "daw-impulse.wav")
+		if err := writeImpulse(name, 2*time.Second); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer os.Remove(name)
+	}
+	ir, err := daw.LoadImpulseResponse(name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	song, err := daw.ParseSong(songText)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	seq := daw.NewSequencer(daw.DefaultFormat, song)
+	seq.Volume = .25
+	seq.Envelope = &daw.ADSR{
+		Attack:  10 * time.Millisecond,
+		Decay:   80 * time.Millisecond,
+		Sustain: .7,
+		Release: 60 * time.Millisecond,
+		Curve:   daw.ExponentialCurve,
+	}
+
+	room := daw.NewConvolver(seq, ir.Normalized())
+	room.SetMix(.4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), song.Duration()+ir.Duration()+time.Second)
+	defer cancel()
+	daw.Play(ctx, room)
+}
+
+// writeImpulse writes a stereo impulse response of exponentially decaying noise, which is roughly
+// what a recording of a click in a large room sounds like once the first few reflections pass.
+func writeImpulse(name string, length time.Duration) error {
+	format := pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}
+	w, err := daw.CreateWAV(name, format, daw.EncodingInt)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	frames := int(length.Seconds() * float64(format.SampleRate))
+	b := make([]byte, frames*format.SampleSize())
+	for i := 0; i < frames; i++ {
+		// fall by 60 decibels over the whole length
+		decay := math.Pow(10, -3*float64(i)/float64(frames))
+		for c := 0; c < int(format.Channels); c++ {
+			v := int16((rand.Float64()*2 - 1) * decay * math.MaxInt16)
+			at := i*format.SampleSize() + c*2
+			b[at] = byte(v)
+			b[at+1] = byte(v >> 8)
+		}
+	}
+	_, err = w.WritePCM(b)
+	return err
+}
@@ -15,14 +15,40 @@ func main() {
 	pitch := new(daw.Pitch)
 	*pitch = daw.C5
 
+	pr := &daw.PitchReader{
+		Format:   format,
+		Pitch:    pitch,
+		Volume:   0.50,
+		WaveFunc: daw.SinFunc,
+	}
+
 	go func() {
 		scanner := bufio.NewScanner(os.Stdin)
 		for scanner.Scan() {
 			switch scanner.Text() {
 			case "up":
-				*pitch = (*pitch).Up(synth.HalfStep)
+				pr.Control(func(pr *daw.PitchReader) {
+					*pr.Pitch = (*pr.Pitch).Up(synth.HalfStep)
+				})
 			case "down":
-				*pitch = (*pitch).Down(synth.HalfStep)
+				pr.Control(func(pr *daw.PitchReader) {
+					*pr.Pitch = (*pr.Pitch).Down(synth.HalfStep)
+				})
+			case "glide":
+				pr.Control(func(pr *daw.PitchReader) {
+					if pr.Glide == 0 {
+						pr.Glide = 150 * time.Millisecond
+					} else {
+						pr.Glide = 0
+					}
+				})
+			default:
+				// play a named pitch, like C#4
+				if p, err := daw.ParsePitch(scanner.Text()); err == nil {
+					pr.Control(func(pr *daw.PitchReader) {
+						*pr.Pitch = p
+					})
+				}
 			}
 		}
 	}()
@@ -30,12 +56,7 @@ func main() {
 	ch := make(chan daw.Writer)
 	go func() {
 		w := <-ch
-		go daw.Loop(w, &daw.PitchReader{
-			Format:   format,
-			Pitch:    pitch,
-			Volume:   0.50,
-			WaveFunc: daw.SinFunc,
-		})
+		go daw.PlayTo(w, pr)
 		time.Sleep(10 * time.Second)
 	}()
 	daw.VisualWriter(format, ch)
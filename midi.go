@@ -0,0 +1,378 @@
+package daw
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// MIDI status and meta event types understood by ReadMIDI.
+const (
+	midiNoteOff       = 0x80
+	midiNoteOn        = 0x90
+	midiSysEx         = 0xF0
+	midiSysExEscape   = 0xF7
+	midiMeta          = 0xFF
+	metaTrackName     = 0x03
+	metaEndOfTrack    = 0x2F
+	metaTempo         = 0x51
+	metaTimeSignature = 0x58
+	metaKeySignature  = 0x59
+)
+
+// ErrInvalidMIDI is returned when reading data which is not a well formed Standard MIDI File.
+var ErrInvalidMIDI = errors.New("invalid midi file")
+
+// MIDI is a Standard MIDI File converted to a Song. Notes keep the track and channel they were
+// read from.
+type MIDI struct {
+	Song
+	// Format is 0 for a single track file, or 1 for a multi track file.
+	Format uint16
+	// TicksPerBeat is how many MIDI ticks make up a quarter note.
+	TicksPerBeat int
+	// Unhandled holds every event which could not be represented in Song, such as program changes,
+	// controllers, lyrics, or later time signature changes. Their Track indexes Song.Tracks.
+	Unhandled []MIDIEvent
+}
+
+// A MIDIEvent is a raw event from a MIDI track.
+type MIDIEvent struct {
+	Track int
+	Tick  int
+	// Status is the event's status byte, including its channel. Meta events have a Status of 0xFF
+	// and system exclusive events have a Status of 0xF0 or 0xF7.
+	Status byte
+	// MetaType is the type of a meta event.
+	MetaType byte
+	// Data is the event's data, not including its status, meta type, or length.
+	Data []byte
+}
+
+func (e MIDIEvent) String() string {
+	if e.Status == midiMeta {
+		return fmt.Sprintf("track %d tick %d: meta %#02x % x", e.Track, e.Tick, e.MetaType, e.Data)
+	}
+	return fmt.Sprintf("track %d tick %d: %#02x % x", e.Track, e.Tick, e.Status, e.Data)
+}
+
+// LoadMIDI reads the named Standard MIDI File.
+func LoadMIDI(name string) (*MIDI, error) {
+	fl, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fl.Close()
+	return ReadMIDI(bufio.NewReader(fl))
+}
+
+// ReadMIDI reads a type 0 or type 1 Standard MIDI File. Each MIDI track becomes a track of the
+// resulting song. The first tempo is used as the song's BPM, later tempos become TempoChanges,
+// and the first time signature and key signature are used as the song's.
+func ReadMIDI(r io.Reader) (*MIDI, error) {
+	id, header, err := readMIDIChunk(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != "MThd" || len(header) < 6 {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidMIDI)
+	}
+	m := &MIDI{
+		Format:       binary.BigEndian.Uint16(header[0:2]),
+		TicksPerBeat: int(binary.BigEndian.Uint16(header[4:6])),
+	}
+	trackCount := int(binary.BigEndian.Uint16(header[2:4]))
+	if m.Format > 1 {
+		return nil, fmt.Errorf("%w: unsupported format %d", ErrInvalidMIDI, m.Format)
+	}
+	if m.TicksPerBeat&0x8000 != 0 || m.TicksPerBeat == 0 {
+		return nil, fmt.Errorf("%w: unsupported SMPTE time division", ErrInvalidMIDI)
+	}
+	mr := &midiReader{MIDI: m}
+	for len(m.Tracks) < trackCount {
+		id, data, err := readMIDIChunk(r)
+		if err != nil {
+			return nil, err
+		}
+		// Unknown chunk types must be skipped
+		if id != "MTrk" {
+			continue
+		}
+		if err := mr.readTrack(data); err != nil {
+			return nil, fmt.Errorf("track %d: %w", len(m.Tracks), err)
+		}
+	}
+	mr.finish()
+	return m, nil
+}
+
+func readMIDIChunk(r io.Reader) (string, []byte, error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidMIDI, err)
+	}
+	// read only as much as is actually there, rather than trusting the length to allocate
+	size := binary.BigEndian.Uint32(head[4:])
+	data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidMIDI, err)
+	}
+	if len(data) != int(size) {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidMIDI, io.ErrUnexpectedEOF)
+	}
+	return string(head[:4]), data, nil
+}
+
+type midiReader struct {
+	*MIDI
+	tempos     []midiTempo
+	sawTimeSig bool
+	sawKeySig  bool
+}
+
+type midiTempo struct {
+	tick int
+	bpm  float64
+}
+
+type midiKey struct {
+	channel, key byte
+}
+
+type midiHeldNote struct {
+	tick     int
+	velocity byte
+}
+
+func (mr *midiReader) readTrack(data []byte) error {
+	track := Track{}
+	trackIndex := len(mr.Tracks)
+	held := map[midiKey][]midiHeldNote{}
+	var tick int
+	var running byte
+	i := 0
+	for i < len(data) {
+		delta, n := readVLQ(data[i:])
+		if n == 0 {
+			return fmt.Errorf("%w: truncated delta time", ErrInvalidMIDI)
+		}
+		i += n
+		tick += delta
+		if i >= len(data) {
+			return fmt.Errorf("%w: truncated event", ErrInvalidMIDI)
+		}
+
+		status := data[i]
+		if status < 0x80 {
+			// running status: reuse the last channel status for this event's data
+			if running == 0 {
+				return fmt.Errorf("%w: running status without a previous status", ErrInvalidMIDI)
+			}
+			status = running
+		} else {
+			i++
+		}
+
+		switch {
+		case status == midiMeta:
+			// running status does not carry over meta or system exclusive events
+			running = 0
+			if i >= len(data) {
+				return fmt.Errorf("%w: truncated meta event", ErrInvalidMIDI)
+			}
+			metaType := data[i]
+			i++
+			length, n := readVLQ(data[i:])
+			i += n
+			if n == 0 || i+length > len(data) {
+				return fmt.Errorf("%w: truncated meta event", ErrInvalidMIDI)
+			}
+			payload := data[i : i+length]
+			i += length
+			if metaType == metaEndOfTrack {
+				i = len(data)
+				break
+			}
+			if !mr.readMeta(&track, tick, metaType, payload) {
+				mr.unhandled(trackIndex, tick, status, metaType, payload)
+			}
+		case status == midiSysEx || status == midiSysExEscape:
+			running = 0
+			length, n := readVLQ(data[i:])
+			i += n
+			if n == 0 || i+length > len(data) {
+				return fmt.Errorf("%w: truncated system exclusive event", ErrInvalidMIDI)
+			}
+			mr.unhandled(trackIndex, tick, status, 0, data[i:i+length])
+			i += length
+		case status >= 0xF0:
+			return fmt.Errorf("%w: unexpected status %#02x in track", ErrInvalidMIDI, status)
+		default:
+			running = status
+			length := 2
+			if kind := status & 0xF0; kind == 0xC0 || kind == 0xD0 {
+				length = 1
+			}
+			if i+length > len(data) {
+				return fmt.Errorf("%w: truncated channel event", ErrInvalidMIDI)
+			}
+			payload := data[i : i+length]
+			i += length
+
+			kind := status & 0xF0
+			k := midiKey{channel: status & 0x0F, key: payload[0]}
+			switch {
+			case kind == midiNoteOn && payload[1] != 0:
+				held[k] = append(held[k], midiHeldNote{tick: tick, velocity: payload[1]})
+			case kind == midiNoteOff || kind == midiNoteOn:
+				// a note on with zero velocity is a note off
+				if len(held[k]) == 0 {
+					mr.unhandled(trackIndex, tick, status, 0, payload)
+					continue
+				}
+				on := held[k][0]
+				held[k] = held[k][1:]
+				track.Add(mr.note(k, on, tick))
+			default:
+				mr.unhandled(trackIndex, tick, status, 0, payload)
+			}
+		}
+	}
+	// notes never released end with their track
+	for k, ons := range held {
+		for _, on := range ons {
+			track.Add(mr.note(k, on, tick))
+		}
+	}
+	// notes ended above come out of the map in any order, so ties are broken by pitch and channel
+	sort.SliceStable(track.Notes, func(i, j int) bool {
+		a, b := track.Notes[i], track.Notes[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.Pitch != b.Pitch {
+			return a.Pitch < b.Pitch
+		}
+		return a.Channel < b.Channel
+	})
+	mr.Tracks = append(mr.Tracks, track)
+	return nil
+}
+
+func (mr *midiReader) note(k midiKey, on midiHeldNote, offTick int) Note {
+	return Note{
+		Pitch:    FromMIDI(int(k.key)),
+		Start:    mr.beat(on.tick),
+		Duration: mr.beat(offTick - on.tick),
+		Velocity: float64(on.velocity) / 127,
+		Channel:  int(k.channel) + 1,
+	}
+}
+
+func (mr *midiReader) beat(tick int) float64 {
+	return float64(tick) / float64(mr.TicksPerBeat)
+}
+
+// readMeta applies a meta event to the song, reporting whether it could be represented.
+func (mr *midiReader) readMeta(track *Track, tick int, metaType byte, data []byte) bool {
+	switch metaType {
+	case metaTrackName:
+		if track.Name != "" {
+			return false
+		}
+		track.Name = string(data)
+	case metaTempo:
+		if len(data) != 3 {
+			return false
+		}
+		microsPerBeat := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+		if microsPerBeat == 0 {
+			return false
+		}
+		mr.tempos = append(mr.tempos, midiTempo{tick: tick, bpm: 60000000 / float64(microsPerBeat)})
+	case metaTimeSignature:
+		if len(data) != 4 || mr.sawTimeSig || data[1] > 6 {
+			return false
+		}
+		mr.sawTimeSig = true
+		mr.TimeSignature = TimeSignature{
+			Beats: int(data[0]),
+			Unit:  1 << data[1],
+		}
+	case metaKeySignature:
+		if len(data) != 2 || mr.sawKeySig {
+			return false
+		}
+		key, ok := keyFromSignature(int(int8(data[0])), data[1] == 1)
+		if !ok {
+			return false
+		}
+		mr.sawKeySig = true
+		mr.Key = key
+	default:
+		return false
+	}
+	return true
+}
+
+func (mr *midiReader) unhandled(track, tick int, status, metaType byte, data []byte) {
+	mr.Unhandled = append(mr.Unhandled, MIDIEvent{
+		Track:    track,
+		Tick:     tick,
+		Status:   status,
+		MetaType: metaType,
+		Data:     append([]byte{}, data...),
+	})
+}
+
+func (mr *midiReader) finish() {
+	mr.BPM = 120
+	if mr.TimeSignature == (TimeSignature{}) {
+		mr.TimeSignature = CommonTime
+	}
+	sort.SliceStable(mr.tempos, func(i, j int) bool {
+		return mr.tempos[i].tick < mr.tempos[j].tick
+	})
+	for _, t := range mr.tempos {
+		if t.tick == 0 {
+			mr.BPM = t.bpm
+			continue
+		}
+		mr.TempoChanges = append(mr.TempoChanges, TempoChange{
+			Beat: mr.beat(t.tick),
+			BPM:  t.bpm,
+		})
+	}
+}
+
+// keyFromSignature converts a MIDI key signature, a count of sharps (positive) or flats (negative)
+// and whether the key is minor, to a Key in the fourth octave.
+func keyFromSignature(accidentals int, minor bool) (Key, bool) {
+	if accidentals < -7 || accidentals > 7 {
+		return Key{}, false
+	}
+	// each sharp moves the major tonic up a fifth
+	tonic := ((accidentals*int(Perfect5))%12 + 12) % 12
+	if minor {
+		tonic = (tonic + int(Major6)) % 12
+		return Key{Start: FromMIDI(60 + tonic), Pattern: MinorKey}, true
+	}
+	return Key{Start: FromMIDI(60 + tonic), Pattern: MajorKey}, true
+}
+
+// readVLQ reads a MIDI variable length quantity, returning it and how many bytes it took.
+// If b does not hold a complete quantity, it returns 0 bytes read.
+func readVLQ(b []byte) (int, int) {
+	var v int
+	for i := 0; i < len(b) && i < 4; i++ {
+		v = v<<7 | int(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
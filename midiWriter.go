@@ -0,0 +1,282 @@
+package daw
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// DefaultTicksPerBeat is the MIDI time resolution used by WriteMIDI.
+const DefaultTicksPerBeat = 480
+
+// maxVLQ is the largest value a MIDI variable length quantity can hold.
+const maxVLQ = 1<<28 - 1
+
+// ErrMIDIRange is returned when writing a time or length a Standard MIDI File cannot hold, such as a
+// note starting before the song.
+var ErrMIDIRange = errors.New("value out of range for a midi file")
+
+// WriteMIDI writes song to w as a type 1 Standard MIDI File at DefaultTicksPerBeat.
+func WriteMIDI(w io.Writer, song Song) error {
+	m := &MIDI{
+		Song:         song,
+		Format:       1,
+		TicksPerBeat: DefaultTicksPerBeat,
+	}
+	return m.Write(w)
+}
+
+// Save writes this file to the named path, replacing any existing file.
+func (m *MIDI) Save(name string) error {
+	fl, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fl)
+	if err := m.Write(bw); err != nil {
+		fl.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		fl.Close()
+		return err
+	}
+	return fl.Close()
+}
+
+// Write writes this file to w as a type 1 Standard MIDI File, one MIDI track per track of its song,
+// or as a type 0 file if its Format is 0 and it has one track. The song's tempo, time signature and
+// key signature are written to the first track, and Unhandled events are written back to the tracks
+// they came from. Notes are written to their Channel, or to their track's channel; see trackChannel.
+func (m *MIDI) Write(w io.Writer) error {
+	tpb := m.TicksPerBeat
+	if tpb == 0 {
+		tpb = DefaultTicksPerBeat
+	}
+	tracks := m.Tracks
+	if len(tracks) == 0 {
+		tracks = []Track{{}}
+	}
+	format := uint16(1)
+	if m.Format == 0 && len(tracks) == 1 {
+		format = 0
+	}
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint16(header[0:], format)
+	binary.BigEndian.PutUint16(header[2:], uint16(len(tracks)))
+	binary.BigEndian.PutUint16(header[4:], uint16(tpb))
+	if err := writeMIDIChunk(w, "MThd", header); err != nil {
+		return err
+	}
+	for i, t := range tracks {
+		mw := &midiTrackWriter{ticksPerBeat: tpb}
+		if i == 0 {
+			mw.conductor(m.Song)
+		}
+		if t.Name != "" {
+			mw.meta(0, metaTrackName, []byte(t.Name))
+		}
+		trackChannel := m.trackChannel(i)
+		for _, n := range t.Notes {
+			if n.Pitch == Rest {
+				continue
+			}
+			channel := trackChannel
+			if n.Channel >= 1 && n.Channel <= 16 {
+				channel = byte(n.Channel - 1)
+			}
+			key := byte(clampInt(MIDINote(n.Pitch), 0, 127))
+			velocity := byte(127)
+			if n.Velocity != 0 {
+				velocity = byte(clampInt(int(math.Round(n.Velocity*127)), 1, 127))
+			}
+			mw.add(mw.tick(n.Start), midiOrderNoteOn, midiNoteOn|channel, key, velocity)
+			mw.add(mw.tick(n.End()), midiOrderNoteOff, midiNoteOff|channel, key, 0)
+		}
+		for _, e := range m.Unhandled {
+			if e.Track != i {
+				continue
+			}
+			switch e.Status {
+			case midiMeta:
+				mw.meta(e.Tick, e.MetaType, e.Data)
+			case midiSysEx, midiSysExEscape:
+				data := mw.appendVLQ([]byte{e.Status}, len(e.Data))
+				mw.events = append(mw.events, midiWriterEvent{tick: e.Tick, order: midiOrderMeta, data: append(data, e.Data...)})
+			default:
+				mw.add(e.Tick, midiOrderChannel, e.Status, e.Data...)
+			}
+		}
+		data, err := mw.bytes()
+		if err != nil {
+			return fmt.Errorf("track %d: %w", i, err)
+		}
+		if err := writeMIDIChunk(w, "MTrk", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackChannel returns the MIDI channel the notes of a track without a Channel are written to. This
+// is the channel of the track's first unhandled channel event if it has one, so notes will follow
+// program changes. Otherwise, tracks are assigned channels in order, skipping the percussion channel.
+func (m *MIDI) trackChannel(track int) byte {
+	for _, e := range m.Unhandled {
+		if e.Track == track && e.Status >= 0x80 && e.Status < 0xF0 {
+			return e.Status & 0x0F
+		}
+	}
+	channel := track % 15
+	if channel >= 9 {
+		channel++
+	}
+	return byte(channel)
+}
+
+// Events at the same tick are written in this order, so notes ending on a tick release before
+// notes starting on the same tick.
+const (
+	midiOrderMeta = iota
+	midiOrderNoteOff
+	midiOrderChannel
+	midiOrderNoteOn
+)
+
+type midiWriterEvent struct {
+	tick  int
+	order int
+	data  []byte
+}
+
+type midiTrackWriter struct {
+	ticksPerBeat int
+	events       []midiWriterEvent
+	// err is the first value found that could not be written
+	err error
+}
+
+func (mw *midiTrackWriter) tick(beat float64) int {
+	return int(math.Round(beat * float64(mw.ticksPerBeat)))
+}
+
+func (mw *midiTrackWriter) add(tick, order int, status byte, data ...byte) {
+	mw.events = append(mw.events, midiWriterEvent{
+		tick:  tick,
+		order: order,
+		data:  append([]byte{status}, data...),
+	})
+}
+
+func (mw *midiTrackWriter) meta(tick int, metaType byte, payload []byte) {
+	data := mw.appendVLQ([]byte{midiMeta, metaType}, len(payload))
+	mw.events = append(mw.events, midiWriterEvent{
+		tick:  tick,
+		order: midiOrderMeta,
+		data:  append(data, payload...),
+	})
+}
+
+func (mw *midiTrackWriter) conductor(song Song) {
+	ts := song.TimeSignature
+	if ts.Beats == 0 || ts.Unit == 0 {
+		ts = CommonTime
+	}
+	unitPower := byte(math.Round(math.Log2(float64(ts.Unit))))
+	// 24 MIDI clocks per metronome click, 8 32nd notes per quarter note
+	mw.meta(0, metaTimeSignature, []byte{byte(ts.Beats), unitPower, 24, 8})
+	if accidentals, minor, ok := song.Key.Signature(); ok && song.Key.Start != 0 {
+		var mi byte
+		if minor {
+			mi = 1
+		}
+		mw.meta(0, metaKeySignature, []byte{byte(int8(accidentals)), mi})
+	}
+	bpm := song.BPM
+	if bpm == 0 {
+		bpm = 120
+	}
+	mw.tempo(0, bpm)
+	for _, tc := range song.TempoChanges {
+		mw.tempo(mw.tick(tc.Beat), tc.BPM)
+	}
+}
+
+func (mw *midiTrackWriter) tempo(tick int, bpm float64) {
+	micros := int(math.Round(60000000 / bpm))
+	mw.meta(tick, metaTempo, []byte{byte(micros >> 16), byte(micros >> 8), byte(micros)})
+}
+
+// appendVLQ appends v to b as a MIDI variable length quantity, recording an error if it cannot be.
+func (mw *midiTrackWriter) appendVLQ(b []byte, v int) []byte {
+	if v < 0 || v > maxVLQ {
+		if mw.err == nil {
+			mw.err = fmt.Errorf("%w: %v", ErrMIDIRange, v)
+		}
+		return b
+	}
+	return appendVLQ(b, v)
+}
+
+func (mw *midiTrackWriter) bytes() ([]byte, error) {
+	sort.SliceStable(mw.events, func(i, j int) bool {
+		if mw.events[i].tick != mw.events[j].tick {
+			return mw.events[i].tick < mw.events[j].tick
+		}
+		return mw.events[i].order < mw.events[j].order
+	})
+	var out []byte
+	var last int
+	// events are sorted, so only the first can be before the song starts
+	if len(mw.events) != 0 && mw.events[0].tick < 0 {
+		return nil, fmt.Errorf("%w: event at tick %v is before the song starts", ErrMIDIRange, mw.events[0].tick)
+	}
+	for _, e := range mw.events {
+		out = mw.appendVLQ(out, e.tick-last)
+		out = append(out, e.data...)
+		last = e.tick
+	}
+	if mw.err != nil {
+		return nil, mw.err
+	}
+	out = appendVLQ(out, 0)
+	return append(out, midiMeta, metaEndOfTrack, 0), nil
+}
+
+func writeMIDIChunk(w io.Writer, id string, data []byte) error {
+	head := make([]byte, 8)
+	copy(head, id)
+	binary.BigEndian.PutUint32(head[4:], uint32(len(data)))
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// appendVLQ appends v, from 0 to maxVLQ, to b as a MIDI variable length quantity.
+func appendVLQ(b []byte, v int) []byte {
+	var buf [4]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7F)
+	for v >>= 7; v > 0 && i > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7F) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,245 @@
+package daw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMIDIRoundTrip(t *testing.T) {
+	song := Song{
+		BPM:           96,
+		TimeSignature: TimeSignature{Beats: 3, Unit: 4},
+		Key:           Key{Start: FromMIDI(62), Pattern: MajorKey},
+		TempoChanges: []TempoChange{
+			{Beat: 6, BPM: 120},
+			{Beat: 9.5, BPM: 80},
+		},
+		Tracks: []Track{
+			{
+				Name: "lead",
+				Notes: []Note{
+					{Pitch: C4, Start: 0, Duration: 1, Velocity: 100. / 127},
+					{Pitch: E4, Start: 1, Duration: 1. / 480, Velocity: 1},
+					{Pitch: G4, Start: 1 + 7./480, Duration: 2.5, Velocity: 64. / 127},
+				},
+			},
+			{
+				Name: "drums",
+				Notes: []Note{
+					{Pitch: FromMIDI(36), Start: 0, Duration: .5, Velocity: 1, Channel: 10},
+					{Pitch: FromMIDI(42), Start: 0, Duration: .5, Velocity: 1, Channel: 10},
+					{Pitch: C3, Start: 3, Duration: 1, Velocity: 1, Channel: 2},
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteMIDI(&buf, song); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadMIDI(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.BPM != song.BPM {
+		t.Errorf("got bpm %v, want %v", m.BPM, song.BPM)
+	}
+	if m.TimeSignature != song.TimeSignature {
+		t.Errorf("got time signature %v, want %v", m.TimeSignature, song.TimeSignature)
+	}
+	if m.Key.Start != song.Key.Start || !patternEqual(m.Key.Pattern, song.Key.Pattern) {
+		t.Errorf("got key %v %v, want %v %v", m.Key.Start, m.Key.Pattern, song.Key.Start, song.Key.Pattern)
+	}
+	if !reflect.DeepEqual(m.TempoChanges, song.TempoChanges) {
+		t.Errorf("got tempo changes %v, want %v", m.TempoChanges, song.TempoChanges)
+	}
+	if len(m.Tracks) != len(song.Tracks) {
+		t.Fatalf("got %v tracks, want %v", len(m.Tracks), len(song.Tracks))
+	}
+	for i, want := range song.Tracks {
+		got := m.Tracks[i]
+		if got.Name != want.Name {
+			t.Errorf("track %v: got name %q, want %q", i, got.Name, want.Name)
+		}
+		if len(got.Notes) != len(want.Notes) {
+			t.Errorf("track %v: got %v notes, want %v", i, len(got.Notes), len(want.Notes))
+			continue
+		}
+		for j, n := range want.Notes {
+			// notes without a channel are read back on their track's channel
+			if n.Channel == 0 {
+				n.Channel = int(m.trackChannel(i)) + 1
+			}
+			if got.Notes[j] != n {
+				t.Errorf("track %v note %v: got %+v, want %+v", i, j, got.Notes[j], n)
+			}
+		}
+	}
+}
+
+func TestMIDIType0KeepsChannels(t *testing.T) {
+	m := &MIDI{
+		Song: Song{
+			BPM: 120,
+			Tracks: []Track{{Notes: []Note{
+				{Pitch: FromMIDI(38), Start: 0, Duration: 1, Velocity: 1, Channel: 10},
+				{Pitch: C4, Start: 0, Duration: 1, Velocity: 1, Channel: 1},
+			}}},
+		},
+		Format:       0,
+		TicksPerBeat: 96,
+	}
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadMIDI(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Format != 0 {
+		t.Errorf("got format %v, want 0", read.Format)
+	}
+	if !reflect.DeepEqual(read.Tracks, m.Tracks) {
+		t.Errorf("got tracks %+v, want %+v", read.Tracks, m.Tracks)
+	}
+}
+
+func TestMIDIWriteOutOfRange(t *testing.T) {
+	for _, n := range []Note{
+		{Pitch: C4, Start: -1, Duration: 2},
+		{Pitch: C4, Start: 0, Duration: (1<<28)/DefaultTicksPerBeat + 1},
+	} {
+		song := Song{BPM: 120, Tracks: []Track{{Notes: []Note{n}}}}
+		if err := WriteMIDI(&bytes.Buffer{}, song); !errors.Is(err, ErrMIDIRange) {
+			t.Errorf("writing %+v: got error %v, want %v", n, err, ErrMIDIRange)
+		}
+	}
+}
+
+// midiFile builds a Standard MIDI File at 96 ticks per beat from the events of each track, which
+// should include their own end of track event.
+func midiFile(format uint16, tracks ...[]byte) []byte {
+	data := []byte("MThd\x00\x00\x00\x06")
+	data = binary.BigEndian.AppendUint16(data, format)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(tracks)))
+	data = binary.BigEndian.AppendUint16(data, 96)
+	for _, track := range tracks {
+		data = append(data, "MTrk"...)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(track)))
+		data = append(data, track...)
+	}
+	return data
+}
+
+func TestReadMIDI(t *testing.T) {
+	conductor := []byte{
+		0x00, 0xFF, 0x58, 0x04, 0x06, 0x03, 0x18, 0x08, // 6/8
+		0x00, 0xFF, 0x59, 0x02, 0xFD, 0x01, // three flats, minor: C minor
+		0x00, 0xFF, 0x51, 0x03, 0x09, 0x27, 0xC0, // 100 bpm
+		0x81, 0x40, 0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20, // 120 bpm at beat 2
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	notes := []byte{
+		0x00, 0xFF, 0x03, 0x04, 'b', 'a', 's', 's',
+		0x00, 0xC2, 0x21, // program change, kept as unhandled
+		0x00, 0x92, 0x24, 0x7F,
+		0x30, 0x24, 0x00, // running status, and a note on with no velocity ends the note
+		0x00, 0x2B, 0x40, // running status starts another
+		0x30, 0x82, 0x2B, 0x00,
+		0x00, 0xF0, 0x02, 0x7E, 0xF7, // system exclusive, kept as unhandled
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	m, err := ReadMIDI(bytes.NewReader(midiFile(1, conductor, notes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != 1 || m.TicksPerBeat != 96 || len(m.Tracks) != 2 {
+		t.Fatalf("got format %v, %v ticks per beat, %v tracks", m.Format, m.TicksPerBeat, len(m.Tracks))
+	}
+	if m.BPM != 100 || !reflect.DeepEqual(m.TempoChanges, []TempoChange{{Beat: 2, BPM: 120}}) {
+		t.Errorf("got bpm %v and tempo changes %v", m.BPM, m.TempoChanges)
+	}
+	if m.TimeSignature != (TimeSignature{Beats: 6, Unit: 8}) {
+		t.Errorf("got time signature %v", m.TimeSignature)
+	}
+	if m.Key.Start.Class() != 0 || !patternEqual(m.Key.Pattern, MinorKey) {
+		t.Errorf("got key %v %v, want C minor", m.Key.Start, m.Key.Pattern)
+	}
+	want := Track{
+		Name: "bass",
+		Notes: []Note{
+			{Pitch: FromMIDI(0x24), Start: 0, Duration: .5, Velocity: 1, Channel: 3},
+			{Pitch: FromMIDI(0x2B), Start: .5, Duration: .5, Velocity: 64. / 127, Channel: 3},
+		},
+	}
+	if !reflect.DeepEqual(m.Tracks[1], want) {
+		t.Errorf("got track %+v, want %+v", m.Tracks[1], want)
+	}
+	unhandled := []MIDIEvent{
+		{Track: 1, Tick: 0, Status: 0xC2, Data: []byte{0x21}},
+		{Track: 1, Tick: 96, Status: 0xF0, Data: []byte{0x7E, 0xF7}},
+	}
+	if !reflect.DeepEqual(m.Unhandled, unhandled) {
+		t.Errorf("got unhandled events %v, want %v", m.Unhandled, unhandled)
+	}
+}
+
+func TestReadMIDIInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":           nil,
+		"not midi":        []byte("RIFF\x00\x00\x00\x06\x00\x00\x00\x01\x00\x60"),
+		"format 2":        midiFile(2, []byte{0x00, 0xFF, 0x2F, 0x00}),
+		"truncated delta": midiFile(0, []byte{0x81}),
+		"truncated note":  midiFile(0, []byte{0x00, 0x90, 0x3C}),
+		"truncated meta":  midiFile(0, []byte{0x00, 0xFF, 0x03, 0x10, 'a'}),
+		"lone running":    midiFile(0, []byte{0x00, 0x3C, 0x40}),
+		// running status does not carry over meta events
+		"running after meta": midiFile(0, []byte{0x00, 0x90, 0x3C, 0x40, 0x00, 0xFF, 0x01, 0x00, 0x00, 0x3C, 0x00}),
+	}
+	// the header claims one more track than there is
+	missing := midiFile(1, []byte{0x00, 0xFF, 0x2F, 0x00})
+	missing[11] = 2
+	tests["missing track"] = missing
+	// a track claiming to be far longer than the file
+	huge := append(midiFile(0), "MTrk\xFF\xFF\xFF\xFF\x00"...)
+	huge[11] = 1
+	tests["huge chunk"] = huge
+	for name, data := range tests {
+		if _, err := ReadMIDI(bytes.NewReader(data)); !errors.Is(err, ErrInvalidMIDI) {
+			t.Errorf("%v: got error %v, want %v", name, err, ErrInvalidMIDI)
+		}
+	}
+}
+
+func TestMIDIHeldNotesInOrder(t *testing.T) {
+	data := midiFile(0, []byte{
+		0x00, 0x90, 0x43, 0x64,
+		0x00, 0x90, 0x3C, 0x64,
+		0x00, 0x91, 0x3C, 0x64,
+		0x00, 0x90, 0x40, 0x64,
+		0x00, 0x90, 0x30, 0x64,
+		// none are released before the track ends a beat later
+		0x60, 0xFF, 0x2F, 0x00,
+	})
+	want := []Note{
+		{Pitch: FromMIDI(0x30), Duration: 1, Velocity: 100. / 127, Channel: 1},
+		{Pitch: FromMIDI(0x3C), Duration: 1, Velocity: 100. / 127, Channel: 1},
+		{Pitch: FromMIDI(0x3C), Duration: 1, Velocity: 100. / 127, Channel: 2},
+		{Pitch: FromMIDI(0x40), Duration: 1, Velocity: 100. / 127, Channel: 1},
+		{Pitch: FromMIDI(0x43), Duration: 1, Velocity: 100. / 127, Channel: 1},
+	}
+	// the held notes are kept in a map, so any disorder would show up between reads
+	for i := 0; i < 20; i++ {
+		m, err := ReadMIDI(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(m.Tracks[0].Notes, want) {
+			t.Fatalf("got notes %+v, want %+v", m.Tracks[0].Notes, want)
+		}
+	}
+}
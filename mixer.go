@@ -0,0 +1,226 @@
+package daw
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &Mixer{}
+
+// A Mixer sums any number of input readers into one stream. Inputs may be added and removed while
+// the mixer is being read from. Inputs must share the mixer's sample rate, but may differ in bits
+// and channels; inputs with fewer channels than the mixer repeat theirs across its channels, and
+// inputs with more are folded down, each output channel taking the average of the input channels
+// which line up with it.
+//
+// A Mixer never runs out of data; with no inputs it produces silence. Inputs which reach EOF are
+// removed.
+type Mixer struct {
+	pcm.Format
+	Encoding
+
+	mu     sync.Mutex
+	inputs []*MixerInput
+
+	sum   []float64
+	frame []float64
+}
+
+// A MixerInput is a reader added to a Mixer. Its gain and pan may be changed from any goroutine,
+// and changes are smoothed over MixerSmoothing.
+type MixerInput struct {
+	pcm.Reader
+	gain *Param
+	pan  *Param
+	buf  []byte
+
+	// left and right are the channel multipliers for panned, the last pan value seen
+	panned, left, right float64
+}
+
+// MixerSmoothing is how long changes to a MixerInput's gain and pan take to fully apply.
+var MixerSmoothing = 20 * time.Millisecond
+
+func NewMixer(format pcm.Format) *Mixer {
+	return &Mixer{
+		Format: format,
+	}
+}
+
+// Add starts mixing r at unity gain, centered.
+func (m *Mixer) Add(r pcm.Reader) *MixerInput {
+	in := &MixerInput{
+		Reader: r,
+		gain:   NewParam(1, MixerSmoothing),
+		pan:    NewParam(0, MixerSmoothing),
+		// no pan has been seen yet, so the channel multipliers are computed on the first frame
+		panned: math.NaN(),
+	}
+	m.mu.Lock()
+	m.inputs = append(m.inputs, in)
+	m.mu.Unlock()
+	return in
+}
+
+// Remove stops mixing in. It is safe to remove an input more than once.
+func (m *Mixer) Remove(in *MixerInput) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, in2 := range m.inputs {
+		if in2 == in {
+			m.inputs = append(m.inputs[:i], m.inputs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Len returns how many inputs are currently being mixed.
+func (m *Mixer) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.inputs)
+}
+
+// SetGain sets a linear multiplier for this input's volume.
+func (in *MixerInput) SetGain(gain float64) {
+	in.gain.Set(gain)
+}
+
+func (in *MixerInput) Gain() float64 {
+	return in.gain.Get()
+}
+
+// SetPan places this input between the left (-1) and right (1) channels of a stereo mixer.
+// Panning uses a constant power law, normalized such that a centered input is unchanged.
+func (in *MixerInput) SetPan(pan float64) {
+	if pan > 1 {
+		pan = 1
+	} else if pan < -1 {
+		pan = -1
+	}
+	in.pan.Set(pan)
+}
+
+func (in *MixerInput) Pan() float64 {
+	return in.pan.Get()
+}
+
+func (m *Mixer) ReadPCM(b []byte) (n int, err error) {
+	size := m.Format.SampleSize()
+	if size == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	frames := len(b) / size
+	channels := int(m.Format.Channels)
+	if cap(m.sum) < frames*channels {
+		m.sum = make([]float64, frames*channels)
+	}
+	sum := m.sum[:frames*channels]
+	for i := range sum {
+		sum[i] = 0
+	}
+
+	m.mu.Lock()
+	inputs := make([]*MixerInput, len(m.inputs))
+	copy(inputs, m.inputs)
+	m.mu.Unlock()
+
+	for _, in := range inputs {
+		if err := m.mixInput(in, sum, frames); err != nil {
+			m.Remove(in)
+		}
+	}
+
+	for i := 0; i < frames; i++ {
+		frame := sum[i*channels : (i+1)*channels]
+		for c, v := range frame {
+			frame[c] = softClip(v)
+		}
+		encodeFrame(b[i*size:], m.Format, m.Encoding, frame)
+	}
+	return frames * size, nil
+}
+
+func (m *Mixer) mixInput(in *MixerInput, sum []float64, frames int) error {
+	format := in.PCMFormat()
+	if format.SampleRate != m.Format.SampleRate {
+		return audio.ErrMismatchedPCMFormat
+	}
+	inSize := format.SampleSize()
+	if inSize == 0 {
+		return pcm.ErrUnsupportedBits
+	}
+	if cap(in.buf) < frames*inSize {
+		in.buf = make([]byte, frames*inSize)
+	}
+	buf := in.buf[:frames*inSize]
+	read, err := audio.ReadFull(in.Reader, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	enc := EncodingOf(in.Reader)
+	inChannels := int(format.Channels)
+	outChannels := int(m.Format.Channels)
+	if len(m.frame) < inChannels {
+		m.frame = make([]float64, inChannels)
+	}
+	frame := m.frame[:inChannels]
+	for i := 0; i < read/inSize; i++ {
+		gain := in.gain.Next(format.SampleRate)
+		if pan := in.pan.Next(format.SampleRate); pan != in.panned {
+			angle := (pan + 1) * math.Pi / 4
+			in.panned = pan
+			in.left = math.Cos(angle) * math.Sqrt2
+			in.right = math.Sin(angle) * math.Sqrt2
+		}
+		decodeFrame(buf[i*inSize:], format, enc, frame)
+		out := sum[i*outChannels : (i+1)*outChannels]
+		for c := range out {
+			var v float64
+			switch {
+			case inChannels == outChannels:
+				v = frame[c]
+			case inChannels > outChannels:
+				var count int
+				for ic := c; ic < inChannels; ic += outChannels {
+					v += frame[ic]
+					count++
+				}
+				v /= float64(count)
+			default:
+				v = frame[c%inChannels]
+			}
+			v *= gain
+			if outChannels == 2 {
+				if c == 0 {
+					v *= in.left
+				} else {
+					v *= in.right
+				}
+			}
+			out[c] += v
+		}
+	}
+	return err
+}
+
+// softClip leaves quiet signals untouched and smoothly limits loud ones to [-1, 1].
+func softClip(v float64) float64 {
+	const knee = 0.8
+	if v <= knee && v >= -knee {
+		return v
+	}
+	sign := 1.0
+	if v < 0 {
+		sign = -1
+		v = -v
+	}
+	return sign * (knee + (1-knee)*math.Tanh((v-knee)/(1-knee)))
+}
@@ -0,0 +1,69 @@
+package daw
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// constantReader returns a reader of one frame repeated for frames frames.
+func constantReader(format pcm.Format, frame []float64, frames int) pcm.Reader {
+	size := format.SampleSize()
+	data := make([]byte, size*frames)
+	for i := 0; i < frames; i++ {
+		encodeFrame(data[i*size:], format, EncodingInt, frame)
+	}
+	return &pcm.IOReader{Format: format, Reader: bytes.NewReader(data)}
+}
+
+func TestMixerDownmix(t *testing.T) {
+	out := pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}
+	tests := []struct {
+		name  string
+		in    pcm.Format
+		frame []float64
+		want  []float64
+	}{
+		{"mono", pcm.Format{SampleRate: 44100, Channels: 1, Bits: 16}, []float64{.5}, []float64{.5, .5}},
+		{"stereo", pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16}, []float64{.1, .2}, []float64{.1, .2}},
+		// every channel is heard, not only the first two
+		{"quad", pcm.Format{SampleRate: 44100, Channels: 4, Bits: 16}, []float64{0, 0, .4, .6}, []float64{.2, .3}},
+		{"six", pcm.Format{SampleRate: 44100, Channels: 6, Bits: 8}, []float64{.3, 0, .3, 0, .3, .3}, []float64{.3, .1}},
+	}
+	for _, tt := range tests {
+		m := NewMixer(out)
+		m.Add(constantReader(tt.in, tt.frame, 64))
+		b := make([]byte, out.SampleSize()*64)
+		if _, err := m.ReadPCM(b); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]float64, 2)
+		decodeFrame(b[out.SampleSize()*32:], out, EncodingInt, got)
+		for c := range got {
+			if math.Abs(got[c]-tt.want[c]) > .01 {
+				t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMixerZeroSampleSize(t *testing.T) {
+	m := NewMixer(pcm.Format{SampleRate: 44100, Channels: 0, Bits: 16})
+	if _, err := m.ReadPCM(make([]byte, 64)); !errors.Is(err, pcm.ErrUnsupportedBits) {
+		t.Errorf("got error %v, want %v", err, pcm.ErrUnsupportedBits)
+	}
+
+	// an input with no sample size is removed rather than mixed
+	m = NewMixer(pcm.Format{SampleRate: 44100, Channels: 2, Bits: 16})
+	m.Add(&pcm.IOReader{Format: pcm.Format{SampleRate: 44100, Channels: 2, Bits: 0}, Reader: bytes.NewReader(nil)})
+	if _, err := m.ReadPCM(make([]byte, 64)); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 0 {
+		t.Errorf("got %v inputs, want 0", m.Len())
+	}
+}
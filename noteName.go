@@ -0,0 +1,167 @@
+package daw
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPitchName is returned when parsing a pitch name which is not understood.
+var ErrInvalidPitchName = errors.New("invalid pitch name")
+
+// letterClasses are the pitch classes of the natural notes, from C.
+var letterClasses = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+const letterNames = "CDEFGAB"
+
+// parseLetterName reads a letter name and any sharps or flats from the start of s, returning how
+// many half steps it is above C, which may be negative or more than an octave for names like Cb
+// or B#, and the rest of s.
+func parseLetterName(s string) (semitones int, rest string, ok bool) {
+	if s == "" {
+		return 0, s, false
+	}
+	letter := strings.IndexByte(letterNames, s[0])
+	if letter == -1 {
+		return 0, s, false
+	}
+	semitones = letterClasses[letter]
+	s = s[1:]
+	for {
+		switch {
+		case hasAnyPrefix(&s, "#", "♯"):
+			semitones++
+		case hasAnyPrefix(&s, "b", "♭"):
+			semitones--
+		default:
+			return semitones, s, true
+		}
+	}
+}
+
+// ParsePitch parses a pitch name made of a letter, any number of sharps ('#') or flats ('b'), an
+// octave, and optionally a number of cents to raise or lower it by, like "C#4", "Eb3", "F##2" or
+// "A4+25c". Octaves start at C, so "B#3" is the same pitch as "C4". "Rest" parses as Rest.
+func ParsePitch(name string) (Pitch, error) {
+	if name == "Rest" || name == "rest" {
+		return Rest, nil
+	}
+	invalid := fmt.Errorf("%w: %q", ErrInvalidPitchName, name)
+	semitones, s, ok := parseLetterName(name)
+	if !ok || s == "" {
+		return Rest, invalid
+	}
+	octaveEnd := len(s)
+	if i := strings.IndexAny(s[1:], "+-"); i != -1 {
+		octaveEnd = i + 1
+	}
+	octave, err := strconv.Atoi(s[:octaveEnd])
+	if err != nil {
+		return Rest, invalid
+	}
+	var cents float64
+	if s = s[octaveEnd:]; s != "" {
+		if !strings.HasSuffix(s, "c") {
+			return Rest, invalid
+		}
+		cents, err = strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return Rest, invalid
+		}
+	}
+	note := midiC0 + 12*octave + semitones
+	return FromFractionalMIDI(float64(note) + cents/100), nil
+}
+
+// MustParsePitch calls ParsePitch and panics if it fails.
+func MustParsePitch(name string) Pitch {
+	p, err := ParsePitch(name)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// A Spelling decides whether pitches between the natural notes are named with sharps or flats.
+type Spelling int
+
+const (
+	SharpSpelling Spelling = iota
+	FlatSpelling
+)
+
+func (s Spelling) String() string {
+	if s == FlatSpelling {
+		return "flats"
+	}
+	return "sharps"
+}
+
+var flatPitchClassNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// Spell names this pitch like String, using sharps or flats as s decides.
+func (p Pitch) Spell(s Spelling) string {
+	if s == SharpSpelling {
+		return p.String()
+	}
+	if p <= 0 {
+		return "Rest"
+	}
+	note := MIDINote(p)
+	letter := strings.IndexByte(letterNames, flatPitchClassNames[mod(note, 12)][0])
+	return spellPitch(p, note, letter)
+}
+
+// spellPitch names p, whose closest MIDI note is note, with the given letter, from 0 for C to 6 for B.
+func spellPitch(p Pitch, note, letter int) string {
+	accidental := mod(note-letterClasses[letter]+6, 12) - 6
+	octave := floorDiv(note-accidental-letterClasses[letter]-midiC0, 12)
+	name := string(letterNames[letter])
+	if accidental > 0 {
+		name += strings.Repeat("#", accidental)
+	} else {
+		name += strings.Repeat("b", -accidental)
+	}
+	name += strconv.Itoa(octave)
+	if cents := math.Round(p.CentsFrom(FromMIDI(note))); cents != 0 {
+		name += fmt.Sprintf("%+gc", cents)
+	}
+	return name
+}
+
+// Spelling returns whether this key is written with sharps or flats.
+func (k Key) Spelling() Spelling {
+	if accidentals, _, ok := k.Signature(); ok {
+		if accidentals < 0 {
+			return FlatSpelling
+		}
+		return SharpSpelling
+	}
+	if strings.Contains(leadSheetNames[k.Start.Class()], "b") {
+		return FlatSpelling
+	}
+	return SharpSpelling
+}
+
+// Name names a pitch as it would be written in this key. In keys of seven notes, each degree of
+// the scale is named with its own letter, so that, for example, the seventh degree of A harmonic
+// minor is G# rather than Ab and the fourth degree of F# major is B rather than Cb. Other pitches
+// are spelled as the key's Spelling decides.
+func (k Key) Name(p Pitch) string {
+	if p <= 0 {
+		return "Rest"
+	}
+	note := MIDINote(p)
+	if len(k.Pattern) == 7 && k.Tuning == nil {
+		tonic := k.Start.Spell(k.Spelling())
+		tonicLetter := strings.IndexByte(letterNames, tonic[0])
+		for degree := 1; degree <= 7; degree++ {
+			if k.Degree(degree).Class() == PitchClass(mod(note, 12)) {
+				return spellPitch(p, note, (tonicLetter+degree-1)%7)
+			}
+		}
+	}
+	return p.Spell(k.Spelling())
+}
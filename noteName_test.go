@@ -0,0 +1,132 @@
+package daw
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParsePitch(t *testing.T) {
+	tests := []struct {
+		name string
+		midi float64
+	}{
+		{"C4", 60},
+		{"C#4", 61},
+		{"C♯4", 61},
+		{"Eb3", 51},
+		{"B♭3", 58},
+		{"F##2", 43},
+		{"Abb4", 67},
+		{"A4+25c", 69.25},
+		{"A4-50c", 68.5},
+		{"G4+12.5c", 67.125},
+		// octaves start at C
+		{"B#3", 60},
+		{"Cb4", 59},
+		{"C-1", 0},
+		{"A-1+10c", 9.1},
+	}
+	for _, tt := range tests {
+		p, err := ParsePitch(tt.name)
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if got := p.MIDI(); math.Abs(got-tt.midi) > 1e-9 {
+			t.Errorf("%v: got midi note %v, want %v", tt.name, got, tt.midi)
+		}
+	}
+	if p, err := ParsePitch("Rest"); err != nil || p != Rest {
+		t.Errorf("Rest: got %v, %v", p, err)
+	}
+}
+
+func TestParsePitchInvalid(t *testing.T) {
+	for _, name := range []string{"", "H4", "c4", "C", "C#", "Cx4", "4C", "A4+25", "A4+c", "A4c", "A4+25cc"} {
+		if _, err := ParsePitch(name); !errors.Is(err, ErrInvalidPitchName) {
+			t.Errorf("%q: got error %v, want %v", name, err, ErrInvalidPitchName)
+		}
+	}
+}
+
+func TestSpell(t *testing.T) {
+	tests := []struct {
+		pitch    Pitch
+		spelling Spelling
+		want     string
+	}{
+		{C4, SharpSpelling, "C4"},
+		{C4, FlatSpelling, "C4"},
+		{A4s, SharpSpelling, "A#4"},
+		{A4s, FlatSpelling, "Bb4"},
+		{FromMIDI(61), FlatSpelling, "Db4"},
+		{FromMIDI(11), FlatSpelling, "B-1"},
+		{FromFractionalMIDI(63.25), FlatSpelling, "Eb4+25c"},
+		{FromFractionalMIDI(62.9), FlatSpelling, "Eb4-10c"},
+		{Rest, FlatSpelling, "Rest"},
+	}
+	for _, tt := range tests {
+		if got := tt.pitch.Spell(tt.spelling); got != tt.want {
+			t.Errorf("%v in %v: got %v, want %v", tt.pitch, tt.spelling, got, tt.want)
+		}
+	}
+}
+
+func TestSpellRoundTrip(t *testing.T) {
+	for note := 0; note < 128; note++ {
+		for _, s := range []Spelling{SharpSpelling, FlatSpelling} {
+			name := FromMIDI(note).Spell(s)
+			p, err := ParsePitch(name)
+			if err != nil {
+				t.Errorf("%v: %v", name, err)
+				continue
+			}
+			if got := MIDINote(p); got != note {
+				t.Errorf("%v: got midi note %v, want %v", name, got, note)
+			}
+		}
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		key   Key
+		pitch Pitch
+		want  string
+	}{
+		{Key{Start: C4, Pattern: MajorKey}, FromMIDI(61), "C#4"},
+		{Key{Start: F4, Pattern: MajorKey}, FromMIDI(61), "Db4"},
+		{Key{Start: F4, Pattern: MajorKey}, A4s, "Bb4"},
+		{Key{Start: D4, Pattern: MinorKey}, A4s, "Bb4"},
+		{Key{Start: E4, Pattern: MajorKey}, G4b, "F#4"},
+		// each degree of a seven note scale gets its own letter
+		{Key{Start: A4, Pattern: HarmonicMinorKey}, A4b, "G#4"},
+		{Key{Start: F4s, Pattern: MajorKey}, FromMIDI(77), "E#5"},
+		{Key{Start: D4b, Pattern: MajorKey}, F4s, "Gb4"},
+		{Key{Start: C4, Pattern: MajorKey}, Rest, "Rest"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.Name(tt.pitch); got != tt.want {
+			t.Errorf("%v in %v: got %v, want %v", tt.pitch, tt.key.Start, got, tt.want)
+		}
+	}
+}
+
+func TestKeySpelling(t *testing.T) {
+	tests := []struct {
+		key  Key
+		want Spelling
+	}{
+		{Key{Start: C4, Pattern: MajorKey}, SharpSpelling},
+		{Key{Start: G4, Pattern: MajorKey}, SharpSpelling},
+		{Key{Start: F4, Pattern: MajorKey}, FlatSpelling},
+		{Key{Start: D4, Pattern: MinorKey}, FlatSpelling},
+		{Key{Start: E4, Pattern: MinorKey}, SharpSpelling},
+	}
+	for _, tt := range tests {
+		if got := tt.key.Spelling(); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.key.Start, got, tt.want)
+		}
+	}
+}
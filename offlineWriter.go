@@ -0,0 +1,151 @@
+package daw
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ Writer = &OfflineWriter{}
+
+// An OfflineWriter is a Writer which does not need a sound device. It saves what is written to it
+// to an io.Writer, or to memory if it has none.
+type OfflineWriter struct {
+	pcm.Format
+	// If Realtime is true, WritePCM will block like a sound device would, keeping at most
+	// audio.WriterBufferLengthInSeconds of audio ahead of the wall clock. Otherwise, writes
+	// complete as fast as they can be stored.
+	Realtime bool
+
+	mu       sync.Mutex
+	dst      io.Writer
+	buf      bytes.Buffer
+	written  int64
+	start    time.Time
+	isClosed bool
+}
+
+// NewOfflineWriter creates an OfflineWriter saving audio to dst, or to memory if dst is nil.
+func NewOfflineWriter(format pcm.Format, dst io.Writer) *OfflineWriter {
+	return &OfflineWriter{
+		Format: format,
+		dst:    dst,
+	}
+}
+
+func (ow *OfflineWriter) PCMFormat() pcm.Format {
+	return ow.Format
+}
+
+var errClosed = errors.New("writer is closed")
+
+func (ow *OfflineWriter) WritePCM(b []byte) (n int, err error) {
+	ow.mu.Lock()
+	if ow.isClosed {
+		ow.mu.Unlock()
+		return 0, errClosed
+	}
+	if ow.written == 0 {
+		ow.start = time.Now()
+	}
+	if ow.dst != nil {
+		n, err = ow.dst.Write(b)
+	} else {
+		n, err = ow.buf.Write(b)
+	}
+	ow.written += int64(n)
+	played := ow.duration()
+	start := ow.start
+	ow.mu.Unlock()
+
+	if ow.Realtime {
+		ahead := time.Duration(float64(time.Second) * audio.WriterBufferLengthInSeconds)
+		if wait := time.Until(start.Add(played - ahead)); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	return n, err
+}
+
+// Duration reports how long the audio written so far would take to play.
+func (ow *OfflineWriter) Duration() time.Duration {
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	return ow.duration()
+}
+
+func (ow *OfflineWriter) duration() time.Duration {
+	bps := ow.Format.BytesPerSecond()
+	if bps == 0 {
+		return 0
+	}
+	return time.Duration(float64(ow.written) / float64(bps) * float64(time.Second))
+}
+
+// Bytes returns the audio written so far, if this writer is saving to memory.
+func (ow *OfflineWriter) Bytes() []byte {
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	return ow.buf.Bytes()
+}
+
+// Reader returns a pcm.Reader over the audio written so far, if this writer is saving to memory.
+func (ow *OfflineWriter) Reader() pcm.Reader {
+	return &pcm.IOReader{
+		Format: ow.Format,
+		Reader: bytes.NewReader(ow.Bytes()),
+	}
+}
+
+// Close closes this writer's destination, if it is an io.Closer.
+func (ow *OfflineWriter) Close() error {
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	if ow.isClosed {
+		return nil
+	}
+	ow.isClosed = true
+	if cl, ok := ow.dst.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+// Render copies duration worth of audio from src to dst as fast as dst will accept it. Unlike Play,
+// it does not wait in between copies, so rendering to an OfflineWriter runs faster than real time.
+func Render(dst pcm.Writer, src pcm.Reader, duration time.Duration) error {
+	format := dst.PCMFormat()
+	if src.PCMFormat() != format {
+		return audio.ErrMismatchedPCMFormat
+	}
+	size := format.SampleSize()
+	if size == 0 {
+		return pcm.ErrUnsupportedBits
+	}
+	total := int(duration.Seconds()*float64(format.SampleRate)) * size
+	buf := make([]byte, int(float64(format.BytesPerSecond())*audio.WriterBufferLengthInSeconds)/size*size)
+	for total > 0 {
+		if len(buf) > total {
+			buf = buf[:total]
+		}
+		n, err := audio.ReadFull(src, buf)
+		if n > 0 {
+			if _, werr := dst.WritePCM(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package daw
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// A Param is a value which can be changed from any goroutine while audio is being produced from it.
+// The audio goroutine reads it once per sample with Next, which eases toward the most recently set
+// value instead of jumping to it, avoiding the 'zipper' noise of abrupt parameter changes.
+type Param struct {
+	target    uint64
+	smoothing time.Duration
+
+	current     float64
+	coefficient float64
+	sampleRate  uint32
+	started     bool
+}
+
+// NewParam creates a Param starting at value, which will take about smoothing to reach new values.
+func NewParam(value float64, smoothing time.Duration) *Param {
+	p := &Param{
+		smoothing: smoothing,
+		current:   value,
+	}
+	p.Set(value)
+	return p
+}
+
+// Set changes the value this Param is moving toward. It is safe to call from any goroutine.
+func (p *Param) Set(value float64) {
+	atomic.StoreUint64(&p.target, math.Float64bits(value))
+}
+
+// Get returns the value this Param was last set to. It is safe to call from any goroutine.
+func (p *Param) Get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&p.target))
+}
+
+// Next advances this Param by one sample at sampleRate and returns its smoothed value. It should
+// only be called from the goroutine producing audio. The first call returns the value most recently
+// set, so values set before audio starts apply immediately.
+func (p *Param) Next(sampleRate uint32) float64 {
+	target := p.Get()
+	if p.smoothing <= 0 || p.current == target || !p.started {
+		p.started = true
+		p.current = target
+		return target
+	}
+	if p.sampleRate != sampleRate {
+		p.sampleRate = sampleRate
+		// a one pole filter with this time constant settles within about 5 time constants
+		timeConstant := p.smoothing.Seconds() / 5 * float64(sampleRate)
+		p.coefficient = 1 - math.Exp(-1/timeConstant)
+	}
+	p.current += (target - p.current) * p.coefficient
+	if math.Abs(target-p.current) < 1e-9 {
+		p.current = target
+	}
+	return p.current
+}
+
+// A controlQueue passes functions from any goroutine to the goroutine producing audio without
+// locking, so the producer is never blocked by a controller.
+type controlQueue[T any] struct {
+	head atomic.Pointer[controlNode[T]]
+}
+
+type controlNode[T any] struct {
+	fn   func(T)
+	next *controlNode[T]
+}
+
+func (q *controlQueue[T]) push(fn func(T)) {
+	n := &controlNode[T]{fn: fn}
+	for {
+		n.next = q.head.Load()
+		if q.head.CompareAndSwap(n.next, n) {
+			return
+		}
+	}
+}
+
+// apply runs every queued function on v in the order they were pushed.
+func (q *controlQueue[T]) apply(v T) {
+	var ordered *controlNode[T]
+	for n := q.head.Swap(nil); n != nil; {
+		next := n.next
+		n.next = ordered
+		ordered = n
+		n = next
+	}
+	for n := ordered; n != nil; n = n.next {
+		n.fn(v)
+	}
+}
@@ -1,35 +1,127 @@
 package daw
 
 import (
+	"io"
 	"math"
+	"time"
 
 	"github.com/oakmound/oak/v4/audio/pcm"
 )
 
+// A PitchReader plays a wave at a pitch. Its phase is accumulated one sample at a time from
+// whatever its frequency is at that sample, so changing Pitch, gliding, or modulating it
+// continues the wave from where it was rather than jumping.
 type PitchReader struct {
-	Pitch    *Pitch
-	Phase    int
+	Pitch *Pitch
+	// Phase is how far through the current cycle of its wave this reader is, from 0 to 2π.
+	Phase    float64
 	WaveFunc func(*PitchReader) float64
 	Volume   float64
+	// If Glide is set, changes to Pitch slide to the new pitch over about this long.
+	Glide time.Duration
+	// If FM is set, it returns an offset in hz to add to Pitch each sample.
+	FM func(*PitchReader) float64
+	// If Envelope is set, it shapes this reader's volume, and this reader will return io.EOF
+	// once the envelope is done.
+	Envelope *Envelope
 	pcm.Format
+	// Encoding declares whether this reader writes integer or float samples of Format.Bits.
+	Encoding
+	// Smoothing is how long changes to Volume made through Control take to fully apply.
+	Smoothing time.Duration
+
+	controls     controlQueue[*PitchReader]
+	volume       *Param
+	volumeTarget float64
+	// glided is the frequency Glide has reached, and frequency adds FM to it.
+	glided    float64
+	frequency float64
+}
+
+// Frequency returns the frequency in hz this reader is currently playing, including Glide and FM.
+func (pr *PitchReader) Frequency() float64 {
+	if pr.glided == 0 {
+		return float64(*pr.Pitch)
+	}
+	return pr.frequency
+}
+
+func (pr *PitchReader) updateFrequency() {
+	target := float64(*pr.Pitch)
+	if pr.Glide <= 0 || pr.glided <= 0 || target <= 0 {
+		pr.glided = target
+	} else {
+		// glide evenly in pitch, not frequency, settling within about Glide
+		timeConstant := pr.Glide.Seconds() / 5 * float64(pr.SampleRate)
+		coefficient := 1 - math.Exp(-1/timeConstant)
+		pr.glided *= math.Pow(target/pr.glided, coefficient)
+	}
+	pr.frequency = pr.glided
+	if pr.FM != nil {
+		pr.frequency += pr.FM(pr)
+	}
+}
+
+// Control queues fn to change this reader before it produces its next buffer of audio. Control
+// is safe to call from any goroutine, unlike changing this reader's fields or Pitch directly
+// while it is being read from.
+func (pr *PitchReader) Control(fn func(*PitchReader)) {
+	pr.controls.push(fn)
 }
 
-func (pr *PitchReader) nextI32() int32 {
-	pr.Phase++
-	return int32(pr.WaveFunc(pr) * math.MaxInt32)
+func (pr *PitchReader) applyControls() {
+	volume := pr.Volume
+	pr.controls.apply(pr)
+	if pr.Smoothing <= 0 {
+		return
+	}
+	if pr.volume == nil {
+		pr.volume = NewParam(volume, pr.Smoothing)
+		pr.volumeTarget = volume
+		// this reader may already have been playing at volume, so changes from here on are smoothed
+		pr.volume.Next(pr.SampleRate)
+	}
+	if pr.Volume != volume {
+		pr.volumeTarget = pr.Volume
+		pr.volume.Set(pr.Volume)
+		pr.Volume = volume
+	}
+}
+
+// Next returns the next value of this reader's wave and advances it by one sample. It should not
+// be mixed with calls to ReadPCM.
+func (pr *PitchReader) Next() float64 {
+	if pr.volume != nil && pr.Volume != pr.volumeTarget {
+		pr.Volume = pr.volume.Next(pr.SampleRate)
+	}
+	pr.updateFrequency()
+	v := pr.WaveFunc(pr)
+	if pr.Envelope != nil {
+		v *= pr.Envelope.Next(pr.SampleRate)
+	}
+	pr.Phase += 2 * math.Pi * pr.frequency / float64(pr.SampleRate)
+	pr.Phase = math.Mod(pr.Phase, 2*math.Pi)
+	if pr.Phase < 0 {
+		pr.Phase += 2 * math.Pi
+	}
+	return v
 }
 
 func (pr *PitchReader) ReadPCM(data []byte) (n int, err error) {
-	bytesPerI32 := int(pr.Format.Channels) * 4
-	for i := 0; i+bytesPerI32 <= len(data); i += bytesPerI32 {
-		i32 := pr.nextI32()
+	pr.applyControls()
+	if pr.Envelope != nil && pr.Envelope.Done() {
+		return 0, io.EOF
+	}
+	size := pr.Format.SampleSize()
+	if size == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	bytesPerSample := int(pr.Format.Bits / 8)
+	for ; n+size <= len(data); n += size {
+		v := pr.Next()
 		for c := 0; c < int(pr.Format.Channels); c++ {
-			data[i+(4*c)] = byte(i32)
-			data[i+(4*c)+1] = byte(i32 >> 8)
-			data[i+(4*c)+2] = byte(i32 >> 16)
-			data[i+(4*c)+3] = byte(i32 >> 24)
+			encodeSample(data[n+c*bytesPerSample:], pr.Format.Bits, pr.Encoding, v)
 		}
-		n += 4 * int(pr.Format.Channels)
 	}
 	return n, nil
 }
@@ -0,0 +1,54 @@
+package daw
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+func TestPitchReaderFirstControlIsSmoothed(t *testing.T) {
+	pitch := A4
+	pr := &PitchReader{
+		Pitch:     &pitch,
+		Volume:    1,
+		WaveFunc:  func(pr *PitchReader) float64 { return pr.Volume },
+		Format:    pcm.Format{SampleRate: 44100, Channels: 1, Bits: 32},
+		Encoding:  EncodingFloat,
+		Smoothing: 50 * time.Millisecond,
+	}
+	buf := make([]byte, 4*64)
+	read := func() []float64 {
+		n, err := pr.ReadPCM(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]float64, n/4)
+		for i := range out {
+			out[i] = decodeSample(buf[i*4:], 32, EncodingFloat)
+		}
+		return out
+	}
+	for _, v := range read() {
+		if v != 1 {
+			t.Fatalf("before any change got %v, want 1", v)
+		}
+	}
+	for _, change := range []float64{0, 1} {
+		pr.Control(func(pr *PitchReader) { pr.Volume = change })
+		got := read()
+		if got[0] == change || math.Abs(got[0]-(1-change)) > .01 {
+			t.Fatalf("first sample after changing volume to %v is %v, want it to ease away from %v", change, got[0], 1-change)
+		}
+		for i := 1; i < len(got); i++ {
+			if math.Abs(got[i]-change) > math.Abs(got[i-1]-change) {
+				t.Fatalf("after changing volume to %v, sample %v moved away from it: %v then %v", change, i, got[i-1], got[i])
+			}
+		}
+		// let the change finish
+		for i := 0; i < 100; i++ {
+			read()
+		}
+	}
+}
@@ -1,161 +1,276 @@
 package daw
 
-import "github.com/oakmound/oak/v4/audio/synth"
+import (
+	"fmt"
+	"math"
+)
 
-type Pitch = synth.Pitch
+// A Pitch is a frequency in hz. Pitches need not be whole numbers or fall on the named pitches
+// below, so detuned and microtonal pitches can be played as precisely as standard ones.
+type Pitch float64
 
+// Named pitches in twelve tone equal temperament, tuned to A4 = 440 hz. These span octave 0
+// through octave 8, with sharps suffixed 's' and flats suffixed 'b'.
 const (
-	C0  Pitch = 16
-	C0s Pitch = 17
-	D0b Pitch = 17
-	D0  Pitch = 18
-	D0s Pitch = 20
-	E0b Pitch = 20
-	E0  Pitch = 21
-	F0  Pitch = 22
-	F0s Pitch = 23
-	G0b Pitch = 23
-	G0  Pitch = 25
-	G0s Pitch = 26
-	A0b Pitch = 26
-	A0  Pitch = 28
-	A0s Pitch = 29
-	B0b Pitch = 29
-	B0  Pitch = 31
-	C1  Pitch = 33
-	C1s Pitch = 35
-	D1b Pitch = 35
-	D1  Pitch = 37
-	D1s Pitch = 39
-	E1b Pitch = 39
-	E1  Pitch = 41
-	F1  Pitch = 44
-	F1s Pitch = 46
-	G1b Pitch = 46
-	G1  Pitch = 49
-	G1s Pitch = 52
-	A1b Pitch = 52
-	A1  Pitch = 55
-	A1s Pitch = 58
-	B1b Pitch = 58
-	B1  Pitch = 62
-	C2  Pitch = 65
-	C2s Pitch = 69
-	D2b Pitch = 69
-	D2  Pitch = 73
-	D2s Pitch = 78
-	E2b Pitch = 78
-	E2  Pitch = 82
-	F2  Pitch = 87
-	F2s Pitch = 93
-	G2b Pitch = 93
-	G2  Pitch = 98
-	G2s Pitch = 104
-	A2b Pitch = 104
-	A2  Pitch = 110
-	A2s Pitch = 117
-	B2b Pitch = 117
-	B2  Pitch = 124
-	C3  Pitch = 131
-	C3s Pitch = 139
-	D3b Pitch = 139
-	D3  Pitch = 147
-	D3s Pitch = 156
-	E3b Pitch = 156
-	E3  Pitch = 165
-	F3  Pitch = 175
-	F3s Pitch = 185
-	G3b Pitch = 185
-	G3  Pitch = 196
-	G3s Pitch = 208
-	A3b Pitch = 208
-	A3  Pitch = 220
-	A3s Pitch = 233
-	B3b Pitch = 233
-	B3  Pitch = 247
-	C4  Pitch = 262
-	C4s Pitch = 278
-	D4b Pitch = 278
-	D4  Pitch = 294
-	D4s Pitch = 311
-	E4b Pitch = 311
-	E4  Pitch = 330
-	F4  Pitch = 349
-	F4s Pitch = 370
-	G4b Pitch = 370
-	G4  Pitch = 392
-	G4s Pitch = 415
-	A4b Pitch = 415
-	A4  Pitch = 440
-	A4s Pitch = 466
-	B4b Pitch = 466
-	B4  Pitch = 494
-	C5  Pitch = 523
-	C5s Pitch = 554
-	D5b Pitch = 554
-	D5  Pitch = 587
-	D5s Pitch = 622
-	E5b Pitch = 622
-	E5  Pitch = 659
-	F5  Pitch = 699
-	F5s Pitch = 740
-	G5b Pitch = 740
-	G5  Pitch = 784
-	G5s Pitch = 831
-	A5b Pitch = 831
-	A5  Pitch = 880
-	A5s Pitch = 932
-	B5b Pitch = 932
-	B5  Pitch = 988
-	C6  Pitch = 1047
-	C6s Pitch = 1109
-	D6b Pitch = 1109
-	D6  Pitch = 1175
-	D6s Pitch = 1245
-	E6b Pitch = 1245
-	E6  Pitch = 1319
-	F6  Pitch = 1397
-	F6s Pitch = 1475
-	G6b Pitch = 1475
-	G6  Pitch = 1568
-	G6s Pitch = 1661
-	A6b Pitch = 1661
-	A6  Pitch = 1760
-	A6s Pitch = 1865
-	B6b Pitch = 1865
-	B6  Pitch = 1976
-	C7  Pitch = 2093
-	C7s Pitch = 2218
-	D7b Pitch = 2218
-	D7  Pitch = 2349
-	D7s Pitch = 2489
-	E7b Pitch = 2489
-	E7  Pitch = 2637
-	F7  Pitch = 2794
-	F7s Pitch = 2960
-	G7b Pitch = 2960
-	G7  Pitch = 3136
-	G7s Pitch = 3322
-	A7b Pitch = 3322
-	A7  Pitch = 3520
-	A7s Pitch = 3729
-	B7b Pitch = 3729
-	B7  Pitch = 3951
-	C8  Pitch = 4186
-	C8s Pitch = 4435
-	D8b Pitch = 4435
-	D8  Pitch = 4699
-	D8s Pitch = 4978
-	E8b Pitch = 4978
-	E8  Pitch = 5274
-	F8  Pitch = 5588
-	F8s Pitch = 5920
-	G8b Pitch = 5920
-	G8  Pitch = 6272
-	G8s Pitch = 6645
-	A8b Pitch = 6645
-	A8  Pitch = 7040
-	A8s Pitch = 7459
-	B8b Pitch = 7459
-	B8  Pitch = 7902
+	// Rest is not a pitch; it represents silence for the purpose of composition.
+	Rest Pitch = 0
+
+	C0  Pitch = 16.351597831287414
+	C0s Pitch = 17.323914436054505
+	D0b Pitch = 17.323914436054505
+	D0  Pitch = 18.354047994837977
+	D0s Pitch = 19.445436482630058
+	E0b Pitch = 19.445436482630058
+	E0  Pitch = 20.601722307054366
+	F0  Pitch = 21.826764464562746
+	F0s Pitch = 23.12465141947715
+	G0b Pitch = 23.12465141947715
+	G0  Pitch = 24.499714748859326
+	G0s Pitch = 25.956543598746574
+	A0b Pitch = 25.956543598746574
+	A0  Pitch = 27.5
+	A0s Pitch = 29.13523509488062
+	B0b Pitch = 29.13523509488062
+	B0  Pitch = 30.86770632850775
+	C1  Pitch = 32.70319566257483
+	C1s Pitch = 34.64782887210901
+	D1b Pitch = 34.64782887210901
+	D1  Pitch = 36.70809598967594
+	D1s Pitch = 38.890872965260115
+	E1b Pitch = 38.890872965260115
+	E1  Pitch = 41.20344461410875
+	F1  Pitch = 43.653528929125486
+	F1s Pitch = 46.2493028389543
+	G1b Pitch = 46.2493028389543
+	G1  Pitch = 48.999429497718666
+	G1s Pitch = 51.91308719749314
+	A1b Pitch = 51.91308719749314
+	A1  Pitch = 55.0
+	A1s Pitch = 58.27047018976124
+	B1b Pitch = 58.27047018976124
+	B1  Pitch = 61.7354126570155
+	C2  Pitch = 65.40639132514966
+	C2s Pitch = 69.29565774421802
+	D2b Pitch = 69.29565774421802
+	D2  Pitch = 73.41619197935188
+	D2s Pitch = 77.78174593052023
+	E2b Pitch = 77.78174593052023
+	E2  Pitch = 82.4068892282175
+	F2  Pitch = 87.30705785825097
+	F2s Pitch = 92.4986056779086
+	G2b Pitch = 92.4986056779086
+	G2  Pitch = 97.99885899543733
+	G2s Pitch = 103.82617439498628
+	A2b Pitch = 103.82617439498628
+	A2  Pitch = 110.0
+	A2s Pitch = 116.54094037952248
+	B2b Pitch = 116.54094037952248
+	B2  Pitch = 123.47082531403103
+	C3  Pitch = 130.8127826502993
+	C3s Pitch = 138.59131548843604
+	D3b Pitch = 138.59131548843604
+	D3  Pitch = 146.8323839587038
+	D3s Pitch = 155.56349186104046
+	E3b Pitch = 155.56349186104046
+	E3  Pitch = 164.81377845643496
+	F3  Pitch = 174.61411571650194
+	F3s Pitch = 184.9972113558172
+	G3b Pitch = 184.9972113558172
+	G3  Pitch = 195.99771799087463
+	G3s Pitch = 207.65234878997256
+	A3b Pitch = 207.65234878997256
+	A3  Pitch = 220.0
+	A3s Pitch = 233.08188075904496
+	B3b Pitch = 233.08188075904496
+	B3  Pitch = 246.94165062806206
+	C4  Pitch = 261.6255653005986
+	C4s Pitch = 277.1826309768721
+	D4b Pitch = 277.1826309768721
+	D4  Pitch = 293.6647679174076
+	D4s Pitch = 311.1269837220809
+	E4b Pitch = 311.1269837220809
+	E4  Pitch = 329.6275569128699
+	F4  Pitch = 349.2282314330039
+	F4s Pitch = 369.9944227116344
+	G4b Pitch = 369.9944227116344
+	G4  Pitch = 391.99543598174927
+	G4s Pitch = 415.3046975799451
+	A4b Pitch = 415.3046975799451
+	A4  Pitch = 440.0
+	A4s Pitch = 466.1637615180899
+	B4b Pitch = 466.1637615180899
+	B4  Pitch = 493.8833012561241
+	C5  Pitch = 523.2511306011972
+	C5s Pitch = 554.3652619537442
+	D5b Pitch = 554.3652619537442
+	D5  Pitch = 587.3295358348151
+	D5s Pitch = 622.2539674441618
+	E5b Pitch = 622.2539674441618
+	E5  Pitch = 659.2551138257398
+	F5  Pitch = 698.4564628660078
+	F5s Pitch = 739.9888454232688
+	G5b Pitch = 739.9888454232688
+	G5  Pitch = 783.9908719634985
+	G5s Pitch = 830.6093951598903
+	A5b Pitch = 830.6093951598903
+	A5  Pitch = 880.0
+	A5s Pitch = 932.3275230361799
+	B5b Pitch = 932.3275230361799
+	B5  Pitch = 987.7666025122483
+	C6  Pitch = 1046.5022612023945
+	C6s Pitch = 1108.7305239074883
+	D6b Pitch = 1108.7305239074883
+	D6  Pitch = 1174.6590716696303
+	D6s Pitch = 1244.5079348883237
+	E6b Pitch = 1244.5079348883237
+	E6  Pitch = 1318.5102276514797
+	F6  Pitch = 1396.9129257320155
+	F6s Pitch = 1479.9776908465376
+	G6b Pitch = 1479.9776908465376
+	G6  Pitch = 1567.981743926997
+	G6s Pitch = 1661.2187903197805
+	A6b Pitch = 1661.2187903197805
+	A6  Pitch = 1760.0
+	A6s Pitch = 1864.6550460723597
+	B6b Pitch = 1864.6550460723597
+	B6  Pitch = 1975.533205024496
+	C7  Pitch = 2093.004522404789
+	C7s Pitch = 2217.4610478149766
+	D7b Pitch = 2217.4610478149766
+	D7  Pitch = 2349.31814333926
+	D7s Pitch = 2489.0158697766474
+	E7b Pitch = 2489.0158697766474
+	E7  Pitch = 2637.02045530296
+	F7  Pitch = 2793.825851464031
+	F7s Pitch = 2959.955381693075
+	G7b Pitch = 2959.955381693075
+	G7  Pitch = 3135.9634878539946
+	G7s Pitch = 3322.437580639561
+	A7b Pitch = 3322.437580639561
+	A7  Pitch = 3520.0
+	A7s Pitch = 3729.3100921447194
+	B7b Pitch = 3729.3100921447194
+	B7  Pitch = 3951.066410048992
+	C8  Pitch = 4186.009044809578
+	C8s Pitch = 4434.922095629953
+	D8b Pitch = 4434.922095629953
+	D8  Pitch = 4698.63628667852
+	D8s Pitch = 4978.031739553295
+	E8b Pitch = 4978.031739553295
+	E8  Pitch = 5274.04091060592
+	F8  Pitch = 5587.651702928062
+	F8s Pitch = 5919.91076338615
+	G8b Pitch = 5919.91076338615
+	G8  Pitch = 6271.926975707989
+	G8s Pitch = 6644.875161279122
+	A8b Pitch = 6644.875161279122
+	A8  Pitch = 7040.0
+	A8s Pitch = 7458.620184289437
+	B8b Pitch = 7458.620184289437
+	B8  Pitch = 7902.132820097988
 )
+
+// midiC0 is the MIDI note number of C0.
+const midiC0 = 12
+
+// midiA4 is the MIDI note number of A4, which is tuned to 440 hz.
+const midiA4 = 69
+
+// FromMIDI returns the pitch of a MIDI note number, where 60 is C4.
+func FromMIDI(note int) Pitch {
+	return FromFractionalMIDI(float64(note))
+}
+
+// FromFractionalMIDI returns the pitch of a MIDI note number which may fall between notes, where
+// 60.5 is a quarter tone above C4.
+func FromFractionalMIDI(note float64) Pitch {
+	return Pitch(float64(A4) * math.Exp2((note-midiA4)/12))
+}
+
+// MIDINote returns the MIDI note number closest to this pitch.
+func MIDINote(p Pitch) int {
+	return int(math.Round(p.MIDI()))
+}
+
+// MIDI returns the MIDI note number of this pitch, including how far it falls between notes.
+func (p Pitch) MIDI() float64 {
+	return 12*math.Log2(float64(p)/float64(A4)) + midiA4
+}
+
+// Up raises this pitch by s half steps.
+func (p Pitch) Up(s Step) Pitch {
+	return p.Cents(float64(s) * 100)
+}
+
+// Down lowers this pitch by s half steps.
+func (p Pitch) Down(s Step) Pitch {
+	return p.Cents(float64(s) * -100)
+}
+
+// Cents raises this pitch by a number of cents, hundredths of a half step, lowering it if cents
+// is negative.
+func (p Pitch) Cents(cents float64) Pitch {
+	return p * Pitch(math.Exp2(cents/1200))
+}
+
+// CentsFrom returns how many cents this pitch is above other, or a negative count if it is below.
+func (p Pitch) CentsFrom(other Pitch) float64 {
+	return 1200 * math.Log2(float64(p)/float64(other))
+}
+
+// Round returns the named pitch closest to this pitch.
+func (p Pitch) Round() Pitch {
+	if p <= 0 {
+		return Rest
+	}
+	return FromMIDI(MIDINote(p))
+}
+
+// A PitchClass is a pitch without its octave, in twelve tone equal temperament, from 0 for C to
+// 11 for B.
+type PitchClass int
+
+// Class returns the pitch class of the named pitch closest to this pitch.
+func (p Pitch) Class() PitchClass {
+	return PitchClass(mod(MIDINote(p), 12))
+}
+
+// In returns this pitch class in an octave, where C4 is in octave 4.
+func (pc PitchClass) In(octave int) Pitch {
+	return FromMIDI(midiC0 + 12*octave + mod(int(pc), 12))
+}
+
+func (pc PitchClass) String() string {
+	return pitchClassNames[mod(int(pc), 12)]
+}
+
+var pitchClassNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// String names this pitch by its closest named pitch, like "C#4", followed by how many cents it is
+// from that pitch if it is not exactly on it, like "A4+25c".
+func (p Pitch) String() string {
+	if p <= 0 {
+		return "Rest"
+	}
+	note := MIDINote(p)
+	name := pitchClassNames[mod(note, 12)] + fmt.Sprint(floorDiv(note, 12)-1)
+	cents := math.Round(p.CentsFrom(FromMIDI(note)))
+	if cents != 0 {
+		name += fmt.Sprintf("%+gc", cents)
+	}
+	return name
+}
+
+// mod returns a modulo b, from 0 to b-1 even when a is negative.
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
+
+// floorDiv divides a by b, rounding toward negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,228 @@
+package daw
+
+import (
+	"math"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &Reverb{}
+
+// MaxPreDelay is the longest pre-delay a Reverb can hold.
+var MaxPreDelay = 500 * time.Millisecond
+
+// ReverbSmoothing is how long changes to a Reverb's settings take to fully apply.
+var ReverbSmoothing = 50 * time.Millisecond
+
+// Freeverb's tunings, in samples at 44.1 kHz. The right channel's delays are stretched by
+// reverbStereoSpread samples so the channels decorrelate.
+var (
+	reverbCombTunings    = [...]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllPassTunings = [...]int{556, 441, 341, 225}
+)
+
+const (
+	reverbStereoSpread = 23
+	reverbInputGain    = .015
+	reverbWetScale     = 3
+	reverbDampScale    = .4
+	reverbRoomScale    = .28
+	reverbRoomOffset   = .7
+	reverbAllPassGain  = .5
+)
+
+// A Reverb places another reader in a simulated room, after Jezar's Freeverb: the input is fed
+// through parallel damped comb filters into a series of all-pass filters, once for each of the left
+// and right channels. It is cheap enough to place on a whole mix. Its settings may be changed from
+// any goroutine while it is being read from. After its input ends, a Reverb keeps playing until it
+// has faded away.
+//
+// Stereo and wider formats take left and right from alternating channels; mono formats hear only
+// the left.
+type Reverb struct {
+	effect
+
+	roomSize *Param
+	damping  *Param
+	width    *Param
+	mix      *Param
+	preDelay *Param
+
+	sampleRate uint32
+	left       reverbChannel
+	right      reverbChannel
+	// pre holds MaxPreDelay of the input, summed to mono
+	pre    []float64
+	prePos int
+	// heard holds what the tail listens to: the reverb, and the input entering the pre-delay
+	heard [3]float64
+}
+
+type reverbChannel struct {
+	combs     [len(reverbCombTunings)]reverbComb
+	allPasses [len(reverbAllPassTunings)]reverbAllPass
+}
+
+// A reverbComb is a feedback comb filter with a one pole low pass filter in its feedback path.
+type reverbComb struct {
+	buf      []float64
+	pos      int
+	filtered float64
+}
+
+func (c *reverbComb) process(in, feedback, damping float64) float64 {
+	out := c.buf[c.pos]
+	c.filtered = out*(1-damping) + c.filtered*damping
+	c.buf[c.pos] = in + c.filtered*feedback
+	c.pos = (c.pos + 1) % len(c.buf)
+	return out
+}
+
+type reverbAllPass struct {
+	buf []float64
+	pos int
+}
+
+func (a *reverbAllPass) process(in float64) float64 {
+	delayed := a.buf[a.pos]
+	a.buf[a.pos] = in + delayed*reverbAllPassGain
+	a.pos = (a.pos + 1) % len(a.buf)
+	return delayed - in
+}
+
+func newReverbChannel(sampleRate uint32, spread int) reverbChannel {
+	scale := float64(sampleRate) / 44100
+	size := func(tuning int) int {
+		return clampInt(int(float64(tuning+spread)*scale), 1, math.MaxInt)
+	}
+	var rc reverbChannel
+	for i, t := range reverbCombTunings {
+		rc.combs[i].buf = make([]float64, size(t))
+	}
+	for i, t := range reverbAllPassTunings {
+		rc.allPasses[i].buf = make([]float64, size(t))
+	}
+	return rc
+}
+
+func (rc *reverbChannel) process(in, feedback, damping float64) float64 {
+	var out float64
+	for i := range rc.combs {
+		out += rc.combs[i].process(in, feedback, damping)
+	}
+	for i := range rc.allPasses {
+		out = rc.allPasses[i].process(out)
+	}
+	return out
+}
+
+// NewReverb reverberates r in a medium sized room, with a wide stereo image and a third of its
+// output reverb.
+func NewReverb(r pcm.Reader) *Reverb {
+	return &Reverb{
+		effect:   effect{Reader: r, tail: new(tail)},
+		roomSize: NewParam(.5, ReverbSmoothing),
+		damping:  NewParam(.5, ReverbSmoothing),
+		width:    NewParam(1, ReverbSmoothing),
+		mix:      NewParam(1./3, ReverbSmoothing),
+		preDelay: NewParam(0, ReverbSmoothing),
+	}
+}
+
+// SetRoomSize sets how large the simulated room is, from 0 to 1. Larger rooms ring for longer.
+func (rv *Reverb) SetRoomSize(size float64) {
+	// larger rooms would feed back more than they take in, and ring louder forever
+	rv.roomSize.Set(math.Max(0, math.Min(size, 1)))
+}
+
+func (rv *Reverb) RoomSize() float64 {
+	return rv.roomSize.Get()
+}
+
+// SetDamping sets how quickly high frequencies fade from the reverb, from 0 to 1, as if the room's
+// walls were softer.
+func (rv *Reverb) SetDamping(damping float64) {
+	rv.damping.Set(math.Max(0, math.Min(damping, 1)))
+}
+
+func (rv *Reverb) Damping() float64 {
+	return rv.damping.Get()
+}
+
+// SetWidth sets how different the reverb is between the left and right, from 0, the same, to 1.
+func (rv *Reverb) SetWidth(width float64) {
+	rv.width.Set(width)
+}
+
+func (rv *Reverb) Width() float64 {
+	return rv.width.Get()
+}
+
+// SetMix sets how much of the output is reverb, from 0, only the input, to 1, only reverb.
+func (rv *Reverb) SetMix(mix float64) {
+	rv.mix.Set(mix)
+}
+
+func (rv *Reverb) Mix() float64 {
+	return rv.mix.Get()
+}
+
+// SetPreDelay sets how long after the input the reverb starts, up to MaxPreDelay. Longer
+// pre-delays sound like larger rooms, and keep the reverb from blurring the start of each note.
+func (rv *Reverb) SetPreDelay(delay time.Duration) {
+	rv.preDelay.Set(delay.Seconds())
+}
+
+func (rv *Reverb) PreDelay() time.Duration {
+	return time.Duration(rv.preDelay.Get() * float64(time.Second))
+}
+
+func (rv *Reverb) ReadPCM(b []byte) (n int, err error) {
+	format := rv.PCMFormat()
+	if rv.sampleRate != format.SampleRate {
+		rv.sampleRate = format.SampleRate
+		rv.left = newReverbChannel(format.SampleRate, 0)
+		rv.right = newReverbChannel(format.SampleRate, reverbStereoSpread)
+		rv.pre = make([]float64, int(MaxPreDelay.Seconds()*float64(format.SampleRate))+1)
+		rv.prePos = 0
+	}
+	// the longest comb; the reverb is over once this long has passed in silence
+	longest := len(rv.right.combs[len(rv.right.combs)-1].buf)
+	return rv.process(b, func(frame []float64) {
+		feedback := rv.value(rv.roomSize)*reverbRoomScale + reverbRoomOffset
+		damping := rv.value(rv.damping) * reverbDampScale
+		width := rv.value(rv.width)
+		mix := rv.value(rv.mix)
+		preDelay := int(math.Round(rv.value(rv.preDelay) * float64(format.SampleRate)))
+		preDelay = clampInt(preDelay, 0, len(rv.pre)-1)
+
+		var in float64
+		for _, v := range frame {
+			in += v
+		}
+		entering := in / float64(len(frame)) * reverbInputGain
+		rv.pre[rv.prePos] = entering
+		in = rv.pre[mod(rv.prePos-preDelay, len(rv.pre))]
+		rv.prePos = (rv.prePos + 1) % len(rv.pre)
+
+		left := rv.left.process(in, feedback, damping)
+		right := rv.right.process(in, feedback, damping)
+		rv.heard = [3]float64{left, right, entering / reverbInputGain}
+		rv.tail.listen(rv.heard[:], preDelay+longest)
+
+		wet := mix * reverbWetScale
+		same, cross := wet*(width/2+.5), wet*(1-width)/2
+		if len(frame) == 1 {
+			frame[0] = frame[0]*(1-mix) + left*wet
+			return
+		}
+		for c, v := range frame {
+			if c%2 == 0 {
+				frame[c] = v*(1-mix) + left*same + right*cross
+			} else {
+				frame[c] = v*(1-mix) + right*same + left*cross
+			}
+		}
+	})
+}
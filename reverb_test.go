@@ -0,0 +1,139 @@
+package daw
+
+import (
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// firstSound returns the first frame of samples, interleaved in channels, which is not silent.
+func firstSound(samples []float64, channels int) int {
+	for i, v := range samples {
+		if v != 0 {
+			return i / channels
+		}
+	}
+	return -1
+}
+
+func TestReverbPreDelay(t *testing.T) {
+	for _, preDelay := range []time.Duration{0, 100 * time.Millisecond} {
+		rv := NewReverb(newSamplesReader(44100, 2, impulse(2, 1)))
+		rv.SetMix(1)
+		rv.SetPreDelay(preDelay)
+		out := readSamples(t, rv, 2*10000)
+		// the reverb is first heard through its shortest comb filter
+		want := int(preDelay.Seconds()*44100) + reverbCombTunings[0]
+		if got := firstSound(out, 2); got != want {
+			t.Errorf("%v pre-delay: first heard at frame %v, want %v", preDelay, got, want)
+		}
+	}
+}
+
+func TestReverbDry(t *testing.T) {
+	in := stereoSine(44100, 440)
+	rv := NewReverb(newSamplesReader(44100, 2, append([]float64{}, in...)))
+	rv.SetMix(0)
+	out := readSamples(t, rv, len(in))
+	for i := range in {
+		if out[i] != in[i] {
+			t.Fatalf("sample %v: got %v, want the input %v", i, out[i], in[i])
+		}
+	}
+}
+
+func TestReverbWidth(t *testing.T) {
+	rv := NewReverb(newSamplesReader(44100, 2, impulse(2, 1)))
+	rv.SetMix(1)
+	rv.SetWidth(0)
+	out := readSamples(t, rv, 2*5000)
+	for i := 0; i < len(out); i += 2 {
+		if out[i] != out[i+1] {
+			t.Fatalf("frame %v: left %v and right %v differ at no width", i/2, out[i], out[i+1])
+		}
+	}
+	if firstSound(out[2:], 2) == -1 {
+		t.Error("reverb is silent")
+	}
+}
+
+func TestReverbSettingsClamp(t *testing.T) {
+	rv := NewReverb(newSamplesReader(44100, 1, nil))
+	rv.SetRoomSize(5)
+	rv.SetDamping(-1)
+	if rv.RoomSize() != 1 || rv.Damping() != 0 {
+		t.Errorf("got room size %v and damping %v, want 1 and 0", rv.RoomSize(), rv.Damping())
+	}
+	rv.SetRoomSize(-1)
+	rv.SetDamping(2)
+	if rv.RoomSize() != 0 || rv.Damping() != 1 {
+		t.Errorf("got room size %v and damping %v, want 0 and 1", rv.RoomSize(), rv.Damping())
+	}
+}
+
+// playedFrames reads r a chunk of frames at a time until it ends, returning how many frames it
+// played, or false if it had not ended after limit frames.
+func playedFrames(t *testing.T, r pcm.Reader, chunk, limit int) (int, bool) {
+	t.Helper()
+	size := r.PCMFormat().SampleSize()
+	b := make([]byte, size*chunk)
+	var played int
+	for played < limit {
+		n, err := r.ReadPCM(b)
+		played += n / size
+		if errors.Is(err, io.EOF) {
+			return played, true
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return played, false
+}
+
+func TestReverbTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		roomSize float64
+		channels uint16
+	}{
+		{"small room", 0, 2},
+		{"large room", 1, 2},
+		{"mono", .5, 1},
+	}
+	for _, tt := range tests {
+		rv := NewReverb(newSamplesReader(44100, tt.channels, impulse(int(tt.channels), 1)))
+		rv.SetRoomSize(tt.roomSize)
+		played, ended := playedFrames(t, rv, 256, 60*44100)
+		if !ended || played < 44100/10 {
+			t.Errorf("%v: played %v frames after an impulse, ended %v; want the reverb to ring out then end", tt.name, played, ended)
+		}
+	}
+	// larger rooms ring for longer
+	small := NewReverb(newSamplesReader(44100, 2, impulse(2, 1)))
+	small.SetRoomSize(0)
+	large := NewReverb(newSamplesReader(44100, 2, impulse(2, 1)))
+	large.SetRoomSize(1)
+	smallPlayed, _ := playedFrames(t, small, 256, 60*44100)
+	largePlayed, _ := playedFrames(t, large, 256, 60*44100)
+	if smallPlayed >= largePlayed {
+		t.Errorf("a small room played %v frames and a large room %v", smallPlayed, largePlayed)
+	}
+}
+
+func TestReverbStable(t *testing.T) {
+	in := stereoSine(44100, 100)
+	rv := NewReverb(newSamplesReader(44100, 2, in))
+	rv.SetRoomSize(1)
+	rv.SetDamping(0)
+	rv.SetMix(1)
+	for i, v := range readSamples(t, rv, 4*44100) {
+		if math.IsNaN(v) || math.Abs(v) > 4 {
+			t.Fatalf("sample %v is %v", i, v)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package daw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRomanNumeral is returned when parsing a chord which is not a recognized roman numeral.
+var ErrInvalidRomanNumeral = errors.New("invalid roman numeral")
+
+// A RomanNumeral names a chord by the degree of a key it is built on, like "V7" or "ii6".
+type RomanNumeral struct {
+	// Degree is the degree of the key the chord's root is on, from 1 to 7.
+	Degree int
+	// Accidental raises or lowers the root from the key's degree, like the flat in "bVII".
+	Accidental Step
+	// Chord is the chord built on the root, decided by the numeral's case and symbols rather
+	// than by the key.
+	Chord Chord
+	// Inversion is how many of the chord's lowest pitches are moved up an octave, from the
+	// numeral's figured bass: "6" and "65" are first inversions, "64" and "43" second, and
+	// "42" or "2" third.
+	Inversion int
+	// Of, if set, is the degree of the key this chord is borrowed from, like the second V in "V/V".
+	Of *RomanNumeral
+}
+
+var romanDegrees = map[string]int{
+	"I": 1, "II": 2, "III": 3, "IV": 4, "V": 5, "VI": 6, "VII": 7,
+}
+
+// ParseRomanNumeral parses a roman numeral chord. Upper case numerals are major and lower case
+// numerals are minor. A numeral may be preceded by 'b' or '#' and followed by '°' or 'o' for
+// diminished, 'ø' for half diminished, '+' for augmented, "maj7" or "M7" for a major seventh, and
+// a figured bass inversion such as "7", "6", "64", "65", "43" or "42". A numeral may be followed by
+// '/' and another numeral to borrow it from that degree's key, like "V7/V".
+func ParseRomanNumeral(s string) (RomanNumeral, error) {
+	parts := strings.Split(s, "/")
+	// the last part is the outermost key, so resolve from the right
+	var of *RomanNumeral
+	for i := len(parts) - 1; i >= 0; i-- {
+		rn, err := parseRomanNumeral(parts[i])
+		if err != nil {
+			return RomanNumeral{}, fmt.Errorf("%w: %q", err, s)
+		}
+		rn.Of = of
+		if i == 0 {
+			return rn, nil
+		}
+		of = &rn
+	}
+	return RomanNumeral{}, nil
+}
+
+func parseRomanNumeral(s string) (RomanNumeral, error) {
+	var rn RomanNumeral
+	switch {
+	case strings.HasPrefix(s, "b"):
+		rn.Accidental = -HalfStep
+		s = s[1:]
+	case strings.HasPrefix(s, "#"):
+		rn.Accidental = HalfStep
+		s = s[1:]
+	}
+	numeral := s
+	for i, r := range s {
+		if !strings.ContainsRune("IViv", r) {
+			numeral = s[:i]
+			break
+		}
+	}
+	s = s[len(numeral):]
+	upper := strings.ToUpper(numeral)
+	degree, ok := romanDegrees[upper]
+	if !ok || (numeral != upper && numeral != strings.ToLower(numeral)) {
+		return rn, ErrInvalidRomanNumeral
+	}
+	rn.Degree = degree
+	minor := numeral != upper
+
+	quality := ""
+	for _, q := range []string{"°", "o", "ø", "+", "maj", "M"} {
+		if strings.HasPrefix(s, q) {
+			quality = q
+			s = s[len(q):]
+			break
+		}
+	}
+	seventh := false
+	switch s {
+	case "":
+	case "6":
+		rn.Inversion = 1
+	case "64":
+		rn.Inversion = 2
+	case "7":
+		seventh = true
+	case "65":
+		seventh, rn.Inversion = true, 1
+	case "43":
+		seventh, rn.Inversion = true, 2
+	case "42", "2":
+		seventh, rn.Inversion = true, 3
+	default:
+		return rn, ErrInvalidRomanNumeral
+	}
+
+	switch quality {
+	case "°", "o":
+		rn.Chord = DiminishedTriad
+		if seventh {
+			rn.Chord = DiminishedSeventh
+		}
+	case "ø":
+		rn.Chord = HalfDiminishedSeventh
+	case "+":
+		rn.Chord = Chord{Major3, Minor6}
+		if seventh {
+			rn.Chord = AugmentedSeventh
+		}
+	case "maj", "M":
+		if !seventh {
+			return rn, ErrInvalidRomanNumeral
+		}
+		rn.Chord = MajorSeventh
+		if minor {
+			rn.Chord = MinorMajorSeventh
+		}
+	default:
+		switch {
+		case minor && seventh:
+			rn.Chord = MinorSeventh
+		case minor:
+			rn.Chord = MinorTriad
+		case seventh:
+			rn.Chord = DominantSeventh
+		default:
+			rn.Chord = MajorTriad
+		}
+	}
+	return rn, nil
+}
+
+// minor reports whether this numeral's chord has a minor third.
+func (rn RomanNumeral) minor() bool {
+	return len(rn.Chord) != 0 && rn.Chord[0] == Minor3
+}
+
+// In returns the pitches of this chord in key k. Secondary chords are built in the major or minor
+// key on the degree they are borrowed from, following the case of that degree's numeral.
+func (rn RomanNumeral) In(k Key) []Pitch {
+	k = rn.key(k)
+	return Invert(rn.Chord.WithRootIn(rn.root(k), k.Tuning), rn.Inversion)
+}
+
+func (rn RomanNumeral) root(k Key) Pitch {
+	return k.Degree(rn.Degree).Up(rn.Accidental)
+}
+
+// key returns the key this numeral's degree is counted in.
+func (rn RomanNumeral) key(k Key) Key {
+	if rn.Of == nil {
+		return k
+	}
+	pattern := MajorKey
+	if rn.Of.minor() {
+		pattern = MinorKey
+	}
+	return Key{
+		Start:   rn.Of.root(rn.Of.key(k)),
+		Pattern: pattern,
+		Tuning:  k.Tuning,
+	}
+}
+
+// Numeral returns the pitches of a roman numeral chord in this key, such as "ii7" or "V/V".
+// Roman numerals assume a key with twelve steps to an octave.
+func (k Key) Numeral(numeral string) ([]Pitch, error) {
+	rn, err := ParseRomanNumeral(numeral)
+	if err != nil {
+		return nil, err
+	}
+	return rn.In(k), nil
+}
+
+// Progression returns the pitches of each roman numeral chord in a progression in this key, such
+// as "I-vi-IV-V". Chords may be separated by dashes, en or em dashes, commas, or spaces.
+func (k Key) Progression(progression string) ([][]Pitch, error) {
+	names := strings.FieldsFunc(progression, func(r rune) bool {
+		return strings.ContainsRune("-–—, \t\n", r)
+	})
+	chords := make([][]Pitch, 0, len(names))
+	for _, name := range names {
+		ps, err := k.Numeral(name)
+		if err != nil {
+			return nil, err
+		}
+		chords = append(chords, ps)
+	}
+	return chords, nil
+}
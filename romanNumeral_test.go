@@ -0,0 +1,141 @@
+package daw
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// midiNotes returns the nearest MIDI note of each pitch.
+func midiNotes(ps []Pitch) []int {
+	notes := make([]int, len(ps))
+	for i, p := range ps {
+		notes[i] = MIDINote(p)
+	}
+	return notes
+}
+
+func TestKeyChords(t *testing.T) {
+	cMajor := Key{Start: C4, Pattern: MajorKey}
+	aMinor := Key{Start: A3, Pattern: MinorKey}
+	tests := []struct {
+		name  string
+		chord []Pitch
+		want  []int
+	}{
+		{"I in C", cMajor.Triad(1), []int{60, 64, 67}},
+		{"ii in C", cMajor.Triad(2), []int{62, 65, 69}},
+		{"vii° in C", cMajor.Triad(7), []int{71, 74, 77}},
+		{"V7 in C", cMajor.Seventh(5), []int{67, 71, 74, 77}},
+		{"IV7 in C", cMajor.Seventh(4), []int{65, 69, 72, 76}},
+		{"III in a", aMinor.Triad(3), []int{60, 64, 67}},
+		{"below the start", cMajor.Triad(0), []int{59, 62, 65}},
+		{"ninth", cMajor.Stack(1, 5), []int{60, 64, 67, 71, 74}},
+	}
+	for _, tt := range tests {
+		if got := midiNotes(tt.chord); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRomanNumeral(t *testing.T) {
+	tests := []struct {
+		numeral string
+		want    RomanNumeral
+	}{
+		{"I", RomanNumeral{Degree: 1, Chord: MajorTriad}},
+		{"vi", RomanNumeral{Degree: 6, Chord: MinorTriad}},
+		{"V7", RomanNumeral{Degree: 5, Chord: DominantSeventh}},
+		{"ii7", RomanNumeral{Degree: 2, Chord: MinorSeventh}},
+		{"ii6", RomanNumeral{Degree: 2, Chord: MinorTriad, Inversion: 1}},
+		{"I64", RomanNumeral{Degree: 1, Chord: MajorTriad, Inversion: 2}},
+		{"V65", RomanNumeral{Degree: 5, Chord: DominantSeventh, Inversion: 1}},
+		{"V43", RomanNumeral{Degree: 5, Chord: DominantSeventh, Inversion: 2}},
+		{"V42", RomanNumeral{Degree: 5, Chord: DominantSeventh, Inversion: 3}},
+		{"bVII", RomanNumeral{Degree: 7, Accidental: -HalfStep, Chord: MajorTriad}},
+		{"#iv°", RomanNumeral{Degree: 4, Accidental: HalfStep, Chord: DiminishedTriad}},
+		{"viio7", RomanNumeral{Degree: 7, Chord: DiminishedSeventh}},
+		{"viiø7", RomanNumeral{Degree: 7, Chord: HalfDiminishedSeventh}},
+		{"III+", RomanNumeral{Degree: 3, Chord: Chord{Major3, Minor6}}},
+		{"Imaj7", RomanNumeral{Degree: 1, Chord: MajorSeventh}},
+		{"iM7", RomanNumeral{Degree: 1, Chord: MinorMajorSeventh}},
+		{"V7/V", RomanNumeral{Degree: 5, Chord: DominantSeventh, Of: &RomanNumeral{Degree: 5, Chord: MajorTriad}}},
+		{"V/V/V", RomanNumeral{Degree: 5, Chord: MajorTriad, Of: &RomanNumeral{
+			Degree: 5, Chord: MajorTriad, Of: &RomanNumeral{Degree: 5, Chord: MajorTriad},
+		}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRomanNumeral(tt.numeral)
+		if err != nil {
+			t.Errorf("%v: %v", tt.numeral, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %+v, want %+v", tt.numeral, got, tt.want)
+		}
+	}
+}
+
+func TestParseRomanNumeralInvalid(t *testing.T) {
+	for _, numeral := range []string{"", "X", "IIII", "Vi", "Imaj", "V9", "V7/", "/V", "bb", "ii7b5"} {
+		if _, err := ParseRomanNumeral(numeral); !errors.Is(err, ErrInvalidRomanNumeral) {
+			t.Errorf("%q: got error %v, want %v", numeral, err, ErrInvalidRomanNumeral)
+		}
+	}
+}
+
+func TestKeyNumeral(t *testing.T) {
+	cMajor := Key{Start: C4, Pattern: MajorKey}
+	aMinor := Key{Start: A3, Pattern: MinorKey}
+	tests := []struct {
+		key     Key
+		numeral string
+		want    []int
+	}{
+		{cMajor, "ii7", []int{62, 65, 69, 72}},
+		{cMajor, "V7", []int{67, 71, 74, 77}},
+		{cMajor, "ii6", []int{65, 69, 74}},
+		{cMajor, "V42", []int{77, 79, 83, 86}},
+		{cMajor, "bVII", []int{70, 74, 77}},
+		{cMajor, "V/V", []int{74, 78, 81}},
+		{cMajor, "V7/ii", []int{69, 73, 76, 79}},
+		{cMajor, "viio7/V", []int{78, 81, 84, 87}},
+		// the numeral's case decides the chord, even where it isn't diatonic
+		{aMinor, "V", []int{64, 68, 71}},
+		{aMinor, "v", []int{64, 67, 71}},
+		{aMinor, "iv/iv", []int{67, 70, 74}},
+	}
+	for _, tt := range tests {
+		ps, err := tt.key.Numeral(tt.numeral)
+		if err != nil {
+			t.Errorf("%v: %v", tt.numeral, err)
+			continue
+		}
+		if got := midiNotes(ps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.numeral, got, tt.want)
+		}
+	}
+}
+
+func TestKeyProgression(t *testing.T) {
+	k := Key{Start: C4, Pattern: MajorKey}
+	want := [][]int{{60, 64, 67}, {69, 72, 76}, {65, 69, 72}, {67, 71, 74}}
+	for _, progression := range []string{"I-vi-IV-V", "I–vi–IV–V", "I, vi, IV, V", "I vi\tIV\nV"} {
+		chords, err := k.Progression(progression)
+		if err != nil {
+			t.Errorf("%q: %v", progression, err)
+			continue
+		}
+		got := make([][]int, len(chords))
+		for i, ps := range chords {
+			got[i] = midiNotes(ps)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %v, want %v", progression, got, want)
+		}
+	}
+	if _, err := k.Progression("I-vi-X-V"); !errors.Is(err, ErrInvalidRomanNumeral) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidRomanNumeral)
+	}
+}
@@ -0,0 +1,115 @@
+package daw
+
+import (
+	"math"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// decodeSample reads one sample of the given bit depth and encoding from b, scaled to [-1, 1].
+// 8 bit integer samples are unsigned, all others are signed and little endian.
+func decodeSample(b []byte, bits uint16, enc Encoding) float64 {
+	if enc == EncodingFloat {
+		switch bits {
+		case 32:
+			return float64(math.Float32frombits(uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24))
+		case 64:
+			var u64 uint64
+			for i := 7; i >= 0; i-- {
+				u64 = u64<<8 | uint64(b[i])
+			}
+			return math.Float64frombits(u64)
+		}
+		return 0
+	}
+	switch bits {
+	case 8:
+		return (float64(b[0]) - 128) / 128
+	case 16:
+		return float64(int16(uint16(b[0])|uint16(b[1])<<8)) / -math.MinInt16
+	case 24:
+		// shift into the top of an int32 to sign extend
+		i32 := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(i32) / (1 << 23)
+	case 32:
+		return float64(int32(uint32(b[0])|uint32(b[1])<<8|uint32(b[2])<<16|uint32(b[3])<<24)) / -math.MinInt32
+	}
+	return 0
+}
+
+// encodeSample writes v, clamped to [-1, 1], into b at the given bit depth and encoding.
+func encodeSample(b []byte, bits uint16, enc Encoding, v float64) {
+	if v > 1 {
+		v = 1
+	} else if v < -1 {
+		v = -1
+	}
+	if enc == EncodingFloat {
+		switch bits {
+		case 32:
+			u32 := math.Float32bits(float32(v))
+			b[0] = byte(u32)
+			b[1] = byte(u32 >> 8)
+			b[2] = byte(u32 >> 16)
+			b[3] = byte(u32 >> 24)
+		case 64:
+			u64 := math.Float64bits(v)
+			for i := 0; i < 8; i++ {
+				b[i] = byte(u64 >> (8 * i))
+			}
+		}
+		return
+	}
+	switch bits {
+	case 8:
+		b[0] = byte(math.Round(v*127) + 128)
+	case 16:
+		i16 := int16(math.Round(v * math.MaxInt16))
+		b[0] = byte(i16)
+		b[1] = byte(i16 >> 8)
+	case 24:
+		i32 := int32(math.Round(v * (1<<23 - 1)))
+		b[0] = byte(i32)
+		b[1] = byte(i32 >> 8)
+		b[2] = byte(i32 >> 16)
+	case 32:
+		i32 := int32(math.Round(v * math.MaxInt32))
+		b[0] = byte(i32)
+		b[1] = byte(i32 >> 8)
+		b[2] = byte(i32 >> 16)
+		b[3] = byte(i32 >> 24)
+	}
+}
+
+// decodeFrame reads one sample per channel of format from b into frame.
+func decodeFrame(b []byte, format pcm.Format, enc Encoding, frame []float64) {
+	size := int(format.Bits / 8)
+	for c := range frame {
+		frame[c] = decodeSample(b[c*size:], format.Bits, enc)
+	}
+}
+
+// encodeFrame writes one sample per channel of format from frame into b.
+func encodeFrame(b []byte, format pcm.Format, enc Encoding, frame []float64) {
+	size := int(format.Bits / 8)
+	for c, v := range frame {
+		encodeSample(b[c*size:], format.Bits, enc, v)
+	}
+}
+
+// processFrames decodes each complete frame in b, passes it to fn to modify, and encodes it back
+// in place. frame is reused between calls to avoid allocating.
+func processFrames(b []byte, format pcm.Format, enc Encoding, frame *[]float64, fn func([]float64)) {
+	size := format.SampleSize()
+	if size == 0 {
+		return
+	}
+	if len(*frame) != int(format.Channels) {
+		*frame = make([]float64, format.Channels)
+	}
+	for i := 0; i+size <= len(b); i += size {
+		decodeFrame(b[i:], format, enc, *frame)
+		fn(*frame)
+		encodeFrame(b[i:], format, enc, *frame)
+	}
+}
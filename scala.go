@@ -0,0 +1,221 @@
+package daw
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidScala is returned when reading a Scala scale or keyboard mapping file which is not well
+// formed.
+var ErrInvalidScala = errors.New("invalid scala file")
+
+// LoadScala reads the named Scala scale (.scl) file.
+func LoadScala(name string) (RatioTuning, error) {
+	fl, err := os.Open(name)
+	if err != nil {
+		return RatioTuning{}, err
+	}
+	defer fl.Close()
+	return ReadScala(fl)
+}
+
+// ReadScala reads a Scala scale (.scl) file. Each pitch in the file may be given in cents, like
+// 701.955, or as a ratio, like 3/2 or 2.
+func ReadScala(r io.Reader) (RatioTuning, error) {
+	lines, err := scalaLines(r)
+	if err != nil {
+		return RatioTuning{}, err
+	}
+	// the description may be blank, but no other line may be
+	if len(lines) > 0 {
+		lines = append(lines[:1], nonBlank(lines[1:])...)
+	}
+	if len(lines) < 2 {
+		return RatioTuning{}, fmt.Errorf("%w: missing description or note count", ErrInvalidScala)
+	}
+	rt := RatioTuning{Description: strings.TrimSpace(lines[0])}
+	count, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil || count < 0 {
+		return RatioTuning{}, fmt.Errorf("%w: bad note count %q", ErrInvalidScala, lines[1])
+	}
+	if len(lines)-2 < count {
+		return RatioTuning{}, fmt.Errorf("%w: expected %d notes, found %d", ErrInvalidScala, count, len(lines)-2)
+	}
+	for _, line := range lines[2 : 2+count] {
+		ratio, err := parseScalaPitch(firstField(line))
+		if err != nil {
+			return RatioTuning{}, err
+		}
+		rt.Ratios = append(rt.Ratios, ratio)
+	}
+	return rt, nil
+}
+
+func parseScalaPitch(s string) (float64, error) {
+	if strings.Contains(s, ".") {
+		cents, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: bad cents %q", ErrInvalidScala, s)
+		}
+		return math.Exp2(cents / 1200), nil
+	}
+	num, den := s, "1"
+	if i := strings.IndexByte(s, '/'); i != -1 {
+		num, den = s[:i], s[i+1:]
+	}
+	n, err1 := strconv.ParseUint(num, 10, 64)
+	d, err2 := strconv.ParseUint(den, 10, 64)
+	if err1 != nil || err2 != nil || n == 0 || d == 0 {
+		return 0, fmt.Errorf("%w: bad ratio %q", ErrInvalidScala, s)
+	}
+	return float64(n) / float64(d), nil
+}
+
+// A KeyboardMapping assigns the degrees of a scale to MIDI notes and fixes the frequency of one
+// note, as read from a Scala keyboard mapping (.kbm) file.
+type KeyboardMapping struct {
+	// First and Last are the range of MIDI notes to map.
+	First, Last int
+	// Middle is the MIDI note which plays degree 0 of the scale.
+	Middle int
+	// ReferenceNote is a MIDI note tuned to ReferenceFrequency.
+	ReferenceNote      int
+	ReferenceFrequency float64
+	// OctaveDegree is the degree of the scale each repetition of Mapping moves up by.
+	OctaveDegree int
+	// Mapping holds the scale degree of each note in a repeating pattern starting from Middle, or -1
+	// for notes which should not sound. If it is empty, each note plays the next degree.
+	Mapping []int
+}
+
+// StandardMapping maps each MIDI note to the next degree of a scale starting at C4, with A4
+// tuned to 440 hz.
+var StandardMapping = KeyboardMapping{
+	First:              0,
+	Last:               127,
+	Middle:             60,
+	ReferenceNote:      69,
+	ReferenceFrequency: 440,
+}
+
+// Pitch returns the frequency tuning t plays for a MIDI note, or false if the note is not mapped.
+func (m KeyboardMapping) Pitch(t Tuning, note int) (Pitch, bool) {
+	if note < m.First || note > m.Last {
+		return Rest, false
+	}
+	degree, ok := m.degree(note)
+	if !ok {
+		return Rest, false
+	}
+	// the reference note is tuned as if it were mapped, even if it is not
+	refDegree, _ := m.degree(m.ReferenceNote)
+	root := Pitch(m.ReferenceFrequency).Tuned(t, Step(-refDegree))
+	return root.Tuned(t, Step(degree)), true
+}
+
+// degree returns the scale degree a note plays, or false if it is unmapped. Unmapped notes are given
+// the degree of their place in the mapping, as if each note of the mapping played the next degree.
+func (m KeyboardMapping) degree(note int) (int, bool) {
+	offset := note - m.Middle
+	if len(m.Mapping) == 0 {
+		return offset, true
+	}
+	octaves := floorDiv(offset, len(m.Mapping))
+	index := offset - octaves*len(m.Mapping)
+	if degree := m.Mapping[index]; degree >= 0 {
+		return degree + octaves*m.OctaveDegree, true
+	}
+	return index + octaves*m.OctaveDegree, false
+}
+
+// LoadKeyboardMapping reads the named Scala keyboard mapping (.kbm) file.
+func LoadKeyboardMapping(name string) (KeyboardMapping, error) {
+	fl, err := os.Open(name)
+	if err != nil {
+		return KeyboardMapping{}, err
+	}
+	defer fl.Close()
+	return ReadKeyboardMapping(fl)
+}
+
+// ReadKeyboardMapping reads a Scala keyboard mapping (.kbm) file.
+func ReadKeyboardMapping(r io.Reader) (KeyboardMapping, error) {
+	lines, err := scalaLines(r)
+	if err != nil {
+		return KeyboardMapping{}, err
+	}
+	lines = nonBlank(lines)
+	if len(lines) < 7 {
+		return KeyboardMapping{}, fmt.Errorf("%w: missing keyboard mapping header", ErrInvalidScala)
+	}
+	var header [7]float64
+	for i := range header {
+		v, err := strconv.ParseFloat(firstField(lines[i]), 64)
+		if err != nil {
+			return KeyboardMapping{}, fmt.Errorf("%w: bad header value %q", ErrInvalidScala, lines[i])
+		}
+		header[i] = v
+	}
+	size := int(header[0])
+	m := KeyboardMapping{
+		First:              int(header[1]),
+		Last:               int(header[2]),
+		Middle:             int(header[3]),
+		ReferenceNote:      int(header[4]),
+		ReferenceFrequency: header[5],
+		OctaveDegree:       int(header[6]),
+	}
+	if size < 0 || m.ReferenceFrequency <= 0 {
+		return KeyboardMapping{}, fmt.Errorf("%w: bad keyboard mapping header", ErrInvalidScala)
+	}
+	lines = lines[7:]
+	for i := 0; i < size; i++ {
+		// missing trailing entries are unmapped
+		if i >= len(lines) || firstField(lines[i]) == "x" {
+			m.Mapping = append(m.Mapping, -1)
+			continue
+		}
+		degree, err := strconv.Atoi(firstField(lines[i]))
+		if err != nil || degree < 0 {
+			return KeyboardMapping{}, fmt.Errorf("%w: bad mapping %q", ErrInvalidScala, lines[i])
+		}
+		m.Mapping = append(m.Mapping, degree)
+	}
+	return m, nil
+}
+
+// scalaLines returns the lines of a Scala file which are not comments.
+func scalaLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(line, "!") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func nonBlank(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,141 @@
+package daw
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadScala(t *testing.T) {
+	scl := `! meanquar.scl
+!
+1/4-comma meantone scale. Pietro Aaron's temperament (1523)
+ 5
+!
+ 76.04900
+ 193.15686 the second
+ 5/4
+ 3/2
+
+ 2
+! trailing comments are ignored
+`
+	rt, err := ReadScala(strings.NewReader(scl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Description != "1/4-comma meantone scale. Pietro Aaron's temperament (1523)" {
+		t.Errorf("got description %q", rt.Description)
+	}
+	want := []float64{math.Exp2(76.049 / 1200), math.Exp2(193.15686 / 1200), 1.25, 1.5, 2}
+	if len(rt.Ratios) != len(want) {
+		t.Fatalf("got ratios %v, want %v", rt.Ratios, want)
+	}
+	for i := range want {
+		if math.Abs(rt.Ratios[i]-want[i]) > 1e-12 {
+			t.Errorf("got ratios %v, want %v", rt.Ratios, want)
+			break
+		}
+	}
+
+	// the description may be blank
+	rt, err = ReadScala(strings.NewReader("\n1\n2/1\n"))
+	if err != nil || rt.Description != "" || !reflect.DeepEqual(rt.Ratios, []float64{2}) {
+		t.Errorf("blank description: got %+v, %v", rt, err)
+	}
+}
+
+func TestReadScalaInvalid(t *testing.T) {
+	for _, scl := range []string{
+		"",
+		"description only\n",
+		"missing notes\n3\n100.0\n200.0\n",
+		"bad count\nthree\n",
+		"negative count\n-1\n",
+		"bad cents\n1\n1.2.3\n",
+		"bad ratio\n1\n3/\n",
+		"zero ratio\n1\n0/2\n",
+		"negative ratio\n1\n-3/2\n",
+	} {
+		if _, err := ReadScala(strings.NewReader(scl)); !errors.Is(err, ErrInvalidScala) {
+			t.Errorf("%q: got error %v, want %v", scl, err, ErrInvalidScala)
+		}
+	}
+}
+
+func TestReadKeyboardMapping(t *testing.T) {
+	kbm := `! white keys only
+7
+0
+127
+60
+69
+440.0
+12
+! mapping
+0
+x
+2
+4
+5
+`
+	m, err := ReadKeyboardMapping(strings.NewReader(kbm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := KeyboardMapping{
+		First: 0, Last: 127, Middle: 60, ReferenceNote: 69, ReferenceFrequency: 440, OctaveDegree: 12,
+		// missing trailing entries are unmapped
+		Mapping: []int{0, -1, 2, 4, 5, -1, -1},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+
+	for _, bad := range []string{"7\n0\n127\n", "0\n0\n127\n60\n69\n0\n12\n", "1\n0\n127\n60\n69\n440\n12\n-2\n"} {
+		if _, err := ReadKeyboardMapping(strings.NewReader(bad)); !errors.Is(err, ErrInvalidScala) {
+			t.Errorf("%q: got error %v, want %v", bad, err, ErrInvalidScala)
+		}
+	}
+}
+
+func TestKeyboardMappingPitch(t *testing.T) {
+	near := func(a, b Pitch) bool {
+		return math.Abs(float64(a-b)) < 1e-9
+	}
+	for note, want := range map[int]Pitch{69: A4, 60: C4, 72: C5, 57: A3} {
+		if got, ok := StandardMapping.Pitch(TwelveTET, note); !ok || !near(got, want) {
+			t.Errorf("standard mapping of %v: got %v, %v, want %v", note, got, ok, want)
+		}
+	}
+
+	// C major's white keys on a just scale, with A4 at 440 even though A is unmapped
+	m := KeyboardMapping{
+		First: 48, Last: 84, Middle: 60, ReferenceNote: 69, ReferenceFrequency: 440, OctaveDegree: 12,
+		Mapping: []int{0, -1, 2, -1, 4, 5, -1, 7, -1, -1, -1, 11},
+	}
+	root := Pitch(440 / JustIntonation.Ratio(9))
+	tests := []struct {
+		note int
+		want Pitch
+		ok   bool
+	}{
+		{60, root, true},
+		{64, root * 5 / 4, true},
+		{67, root * 3 / 2, true},
+		{72, root * 2, true},
+		{55, root * 3 / 4, true},
+		{61, Rest, false},
+		{69, Rest, false},
+		{47, Rest, false},
+		{85, Rest, false},
+	}
+	for _, tt := range tests {
+		got, ok := m.Pitch(JustIntonation, tt.note)
+		if ok != tt.ok || !near(got, tt.want) {
+			t.Errorf("note %v: got %v, %v, want %v, %v", tt.note, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package daw
+
+import (
+	"io"
+	"sort"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+var _ pcm.Reader = &Sequencer{}
+
+// A Sequencer plays a Song. Every note starts and stops on the exact sample its beat falls on,
+// independent of how the sequencer is read from. It returns io.EOF once every note has ended.
+type Sequencer struct {
+	Song
+	pcm.Format
+	Encoding
+	// WaveFunc and Volume are used to create a PitchReader for each note.
+	WaveFunc func(*PitchReader) float64
+	Volume   float64
+	// If Envelope is set, each note is shaped by it, releasing when the note ends. Otherwise notes
+	// start and stop immediately.
+	Envelope *ADSR
+
+	scheduled []scheduledNote
+	next      int
+	voices    []*sequencerVoice
+	sample    int
+	frame     []float64
+}
+
+type scheduledNote struct {
+	Note
+	start, end int
+}
+
+type sequencerVoice struct {
+	PitchReader
+	pitch    Pitch
+	velocity float64
+	end      int
+}
+
+func NewSequencer(format pcm.Format, song Song) *Sequencer {
+	return &Sequencer{
+		Song:     song,
+		Format:   format,
+		WaveFunc: SinFunc,
+		Volume:   .5,
+	}
+}
+
+func (s *Sequencer) schedule() {
+	s.scheduled = s.scheduled[:0]
+	for _, t := range s.Tracks {
+		for _, n := range t.Notes {
+			sn := scheduledNote{
+				Note:  n,
+				start: s.Song.Sample(n.Start, s.SampleRate),
+				end:   s.Song.Sample(n.End(), s.SampleRate),
+			}
+			if n.Pitch == Rest || sn.end <= sn.start {
+				continue
+			}
+			s.scheduled = append(s.scheduled, sn)
+		}
+	}
+	sort.SliceStable(s.scheduled, func(i, j int) bool {
+		return s.scheduled[i].start < s.scheduled[j].start
+	})
+}
+
+// Reset prepares this sequencer to play its song from the start. Call Reset after changing the song.
+func (s *Sequencer) Reset() {
+	s.schedule()
+	s.next = 0
+	s.voices = s.voices[:0]
+	s.sample = 0
+}
+
+func (s *Sequencer) done() bool {
+	return s.next >= len(s.scheduled) && len(s.voices) == 0
+}
+
+func (s *Sequencer) ReadPCM(b []byte) (n int, err error) {
+	if s.scheduled == nil {
+		s.Reset()
+	}
+	if s.done() {
+		return 0, io.EOF
+	}
+	size := s.Format.SampleSize()
+	if size == 0 {
+		return 0, pcm.ErrUnsupportedBits
+	}
+	if len(s.frame) != int(s.Channels) {
+		s.frame = make([]float64, s.Channels)
+	}
+	for ; n+size <= len(b); n += size {
+		var v float64
+		if !s.done() {
+			v = s.nextSample()
+		}
+		for c := range s.frame {
+			s.frame[c] = v
+		}
+		encodeFrame(b[n:], s.Format, s.Encoding, s.frame)
+	}
+	return n, nil
+}
+
+func (s *Sequencer) nextSample() float64 {
+	for s.next < len(s.scheduled) && s.scheduled[s.next].start <= s.sample {
+		s.startVoice(s.scheduled[s.next])
+		s.next++
+	}
+	var v float64
+	live := s.voices[:0]
+	for _, voice := range s.voices {
+		if s.sample == voice.end && voice.Envelope != nil {
+			voice.Envelope.NoteOff()
+		}
+		v += voice.Next() * voice.velocity
+		if voice.playing(s.sample + 1) {
+			live = append(live, voice)
+		}
+	}
+	s.voices = live
+	s.sample++
+	return softClip(v)
+}
+
+func (s *Sequencer) startVoice(n scheduledNote) {
+	velocity := n.Velocity
+	if velocity == 0 {
+		velocity = 1
+	}
+	voice := &sequencerVoice{
+		pitch:    n.Pitch,
+		velocity: velocity,
+		end:      n.end,
+	}
+	voice.PitchReader = PitchReader{
+		Pitch:    &voice.pitch,
+		WaveFunc: s.WaveFunc,
+		Volume:   s.Volume,
+		Format:   s.Format,
+	}
+	if s.Envelope != nil {
+		voice.Envelope = s.Envelope.Start()
+	}
+	s.voices = append(s.voices, voice)
+}
+
+// playing reports whether this voice has anything left to play at the given sample.
+func (v *sequencerVoice) playing(sample int) bool {
+	if v.Envelope != nil {
+		return !v.Envelope.Done()
+	}
+	return sample < v.end
+}
@@ -0,0 +1,61 @@
+package daw
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+func TestSequencerSampleAccurate(t *testing.T) {
+	song := Song{
+		BPM:    120,
+		Tracks: []Track{{Notes: []Note{{Pitch: A4, Start: 1, Duration: .5}}}},
+	}
+	format := pcm.Format{SampleRate: 44100, Channels: 1, Bits: 16}
+	// reading in uneven buffers must not move the note
+	for _, bufSize := range []int{2, 998, 4096} {
+		s := NewSequencer(format, song)
+		s.WaveFunc = func(pr *PitchReader) float64 { return pr.Volume }
+		var samples []float64
+		buf := make([]byte, bufSize)
+		for {
+			n, err := s.ReadPCM(buf)
+			for i := 0; i+2 <= n; i += 2 {
+				samples = append(samples, decodeSample(buf[i:], 16, EncodingInt))
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		start, end := -1, -1
+		for i, v := range samples {
+			if v != 0 {
+				if start == -1 {
+					start = i
+				}
+				end = i + 1
+			}
+		}
+		if start != 22050 || end != 33075 {
+			t.Errorf("reading %v bytes at a time: note played from sample %v to %v, want 22050 to 33075", bufSize, start, end)
+		}
+	}
+}
+
+func TestSequencerZeroSampleSize(t *testing.T) {
+	song := Song{BPM: 120, Tracks: []Track{{Notes: []Note{{Pitch: A4, Duration: 1}}}}}
+	for _, format := range []pcm.Format{
+		{SampleRate: 44100, Channels: 0, Bits: 16},
+		{SampleRate: 44100, Channels: 2, Bits: 0},
+	} {
+		s := NewSequencer(format, song)
+		if _, err := s.ReadPCM(make([]byte, 64)); !errors.Is(err, pcm.ErrUnsupportedBits) {
+			t.Errorf("%+v: got error %v, want %v", format, err, pcm.ErrUnsupportedBits)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package daw
+
+import (
+	"math"
+	"time"
+)
+
+// Note durations, in beats. A beat is always a quarter note, regardless of time signature.
+const (
+	WholeNote     float64 = 4
+	HalfNote      float64 = 2
+	QuarterNote   float64 = 1
+	EighthNote    float64 = .5
+	SixteenthNote float64 = .25
+)
+
+// Dotted extends a note duration by half.
+func Dotted(duration float64) float64 {
+	return duration * 1.5
+}
+
+// A Note is a pitch played for some span of a song. Start and Duration are measured in beats.
+type Note struct {
+	Pitch    Pitch
+	Start    float64
+	Duration float64
+	// Velocity scales how loud this note is, from 0 to 1. A zero Velocity is played at full volume.
+	Velocity float64
+	// Channel is the MIDI channel this note is played on, numbered from 1 to 16 as MIDI channels
+	// usually are. A zero Channel plays on its track's channel.
+	Channel int
+}
+
+func (n Note) End() float64 {
+	return n.Start + n.Duration
+}
+
+// Notes returns one note for each pitch of this chord built on root.
+func (c Chord) Notes(root Pitch, start, duration float64) []Note {
+	pitches := c.WithRoot(root)
+	notes := make([]Note, len(pitches))
+	for i, p := range pitches {
+		notes[i] = Note{
+			Pitch:    p,
+			Start:    start,
+			Duration: duration,
+		}
+	}
+	return notes
+}
+
+type TimeSignature struct {
+	// Beats is how many Unit notes make up a measure.
+	Beats int
+	// Unit is the note value counted as one beat of a measure, e.g. 4 for a quarter note.
+	Unit int
+}
+
+var CommonTime = TimeSignature{Beats: 4, Unit: 4}
+
+type Track struct {
+	Name  string
+	Notes []Note
+}
+
+func (t *Track) Add(notes ...Note) {
+	t.Notes = append(t.Notes, notes...)
+}
+
+// A TempoChange sets a new BPM from Beat onward.
+type TempoChange struct {
+	Beat float64
+	BPM  float64
+}
+
+// A Song is a set of tracks of notes played at some tempo.
+type Song struct {
+	// BPM is how many quarter notes are played per minute.
+	BPM float64
+	// TempoChanges, sorted by beat, change BPM partway through the song.
+	TempoChanges  []TempoChange
+	TimeSignature TimeSignature
+	Key           Key
+	Tracks        []Track
+}
+
+// MeasureLength returns how many beats are in one measure of this song.
+func (s Song) MeasureLength() float64 {
+	ts := s.TimeSignature
+	if ts.Beats == 0 || ts.Unit == 0 {
+		ts = CommonTime
+	}
+	return float64(ts.Beats) * QuarterNote * 4 / float64(ts.Unit)
+}
+
+// Measure returns the beat the given measure starts on, counting from zero.
+func (s Song) Measure(measure int) float64 {
+	return float64(measure) * s.MeasureLength()
+}
+
+// Seconds returns how far into this song the given beat is.
+func (s Song) Seconds(beat float64) float64 {
+	var seconds, at float64
+	bpm := s.BPM
+	for _, tc := range s.TempoChanges {
+		if tc.Beat >= beat {
+			break
+		}
+		seconds += (tc.Beat - at) * 60 / bpm
+		at, bpm = tc.Beat, tc.BPM
+	}
+	return seconds + (beat-at)*60/bpm
+}
+
+// Sample returns the index of the sample the given beat starts on at sampleRate.
+func (s Song) Sample(beat float64, sampleRate uint32) int {
+	return int(math.Round(s.Seconds(beat) * float64(sampleRate)))
+}
+
+// Length returns the beat the last note of this song ends on.
+func (s Song) Length() float64 {
+	var length float64
+	for _, t := range s.Tracks {
+		for _, n := range t.Notes {
+			if n.End() > length {
+				length = n.End()
+			}
+		}
+	}
+	return length
+}
+
+func (s Song) Duration() time.Duration {
+	return time.Duration(s.Seconds(s.Length()) * float64(time.Second))
+}
+
+// BeatDuration returns how long a number of beats lasts at a tempo of bpm.
+func BeatDuration(beats, bpm float64) time.Duration {
+	return time.Duration(beats * 60 / bpm * float64(time.Second))
+}
@@ -0,0 +1,376 @@
+package daw
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSongText is returned when reading song text which is not well formed.
+var ErrInvalidSongText = errors.New("invalid song text")
+
+// LoadSong reads the named song text file; see ReadSong.
+func LoadSong(name string) (Song, error) {
+	fl, err := os.Open(name)
+	if err != nil {
+		return Song{}, err
+	}
+	defer fl.Close()
+	return ReadSong(fl)
+}
+
+// ParseSong parses song text; see ReadSong.
+func ParseSong(text string) (Song, error) {
+	return ReadSong(strings.NewReader(text))
+}
+
+// ReadSong reads a song written in a compact text notation, like:
+//
+//	% comments start with a percent sign
+//	tempo: 116
+//	time: 4/4
+//	key: D major
+//
+//	voice: chords
+//	"G"5/8. "G"5/8. "A"5/8 "A"5/4 r/8 "A"5/8 |
+//	voice: melody
+//	B5/4 A5/8 G5 (3 F#5/8 G5 A5 B5/2 |
+//
+// Each line is either a header, "name: value", or a series of notes. Notes are separated by
+// spaces; bar lines ('|') are ignored. A note is a pitch name, like C#4 or A4+25c, followed by an
+// optional duration: "/1" for a whole note, "/4" for a quarter note, "/8" for an eighth, and so on,
+// with a '.' for each dot. Notes without a duration or octave take them from the note before.
+// "r" is a rest. Pitches in brackets, like [C4 E4 G4]/2, play together, as do the pitches of a
+// quoted chord symbol, like "Am7"/2, whose bass is in octave 4 or in the octave written after it,
+// like "Am7"3/2. A '~' after a note ties it to the same pitches of the next note. "(3" plays the
+// next three notes in the time of two, and "(p:q" the next p notes in the time of q.
+//
+// The headers are:
+//
+//	tempo: beats per minute, of quarter notes. After the first note, this changes the tempo.
+//	time: the time signature, like 3/4.
+//	key: the key, like "F# minor", "Bb major" or "D dorian".
+//	voice: a name. Following notes are added to the track of this name, starting where that
+//	track last ended, so voices may be written a few measures at a time.
+func ReadSong(r io.Reader) (Song, error) {
+	p := &songParser{
+		song: Song{
+			BPM:           120,
+			TimeSignature: CommonTime,
+		},
+		voices: map[string]*songVoice{},
+	}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if err := p.line(scanner.Text()); err != nil {
+			return Song{}, fmt.Errorf("%w: line %d: %v", ErrInvalidSongText, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Song{}, err
+	}
+	// voices written one after another may change tempo out of order
+	sort.SliceStable(p.song.TempoChanges, func(i, j int) bool {
+		return p.song.TempoChanges[i].Beat < p.song.TempoChanges[j].Beat
+	})
+	return p.song, nil
+}
+
+type songParser struct {
+	song    Song
+	voices  map[string]*songVoice
+	current *songVoice
+	started bool
+}
+
+// songVoice is the state of one track while it is being written.
+type songVoice struct {
+	track    int
+	beat     float64
+	duration float64
+	octave   int
+	// tied holds the notes the next note may continue.
+	tied []int
+	// tuplet scales the durations of the next tupletNotes notes.
+	tuplet      float64
+	tupletNotes int
+}
+
+var songKeyPatterns = map[string]KeyPattern{
+	"major":          MajorKey,
+	"minor":          MinorKey,
+	"ionian":         IonianMode,
+	"dorian":         DorianMode,
+	"phrygian":       PhrygianMode,
+	"lydian":         LydianMode,
+	"mixolydian":     MixolydianMode,
+	"aeolian":        AeolianMode,
+	"locrian":        LocrianMode,
+	"harmonic minor": HarmonicMinorKey,
+	"melodic minor":  MelodicMinorKey,
+}
+
+func (p *songParser) line(line string) error {
+	if i := strings.IndexByte(line, '%'); i != -1 {
+		line = line[:i]
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	if name, value, ok := strings.Cut(line, ":"); ok && !strings.ContainsAny(name, " \"[(") {
+		return p.header(strings.ToLower(name), strings.TrimSpace(value))
+	}
+	for _, tok := range songTokens(line) {
+		if err := p.token(tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *songParser) header(name, value string) error {
+	switch name {
+	case "tempo":
+		bpm, err := strconv.ParseFloat(value, 64)
+		if err != nil || bpm <= 0 {
+			return fmt.Errorf("bad tempo %q", value)
+		}
+		if !p.started {
+			p.song.BPM = bpm
+			return nil
+		}
+		p.song.TempoChanges = append(p.song.TempoChanges, TempoChange{Beat: p.voice().beat, BPM: bpm})
+	case "time":
+		var ts TimeSignature
+		if _, err := fmt.Sscanf(value, "%d/%d", &ts.Beats, &ts.Unit); err != nil || ts.Beats <= 0 || ts.Unit <= 0 {
+			return fmt.Errorf("bad time signature %q", value)
+		}
+		p.song.TimeSignature = ts
+	case "key":
+		tonic, mode, _ := strings.Cut(value, " ")
+		start, err := ParsePitch(tonic + "4")
+		pattern, ok := songKeyPatterns[strings.ToLower(strings.TrimSpace(mode))]
+		if err != nil || !ok {
+			return fmt.Errorf("bad key %q", value)
+		}
+		p.song.Key = Key{Start: start, Pattern: pattern}
+	case "voice":
+		v, ok := p.voices[value]
+		if !ok {
+			v = &songVoice{track: len(p.song.Tracks), duration: QuarterNote, octave: 4}
+			p.song.Tracks = append(p.song.Tracks, Track{Name: value})
+			p.voices[value] = v
+		}
+		p.current = v
+	default:
+		return fmt.Errorf("unknown header %q", name)
+	}
+	return nil
+}
+
+// voice returns the voice notes are being added to, starting an unnamed one if there is none.
+func (p *songParser) voice() *songVoice {
+	if p.current == nil {
+		if err := p.header("voice", ""); err != nil {
+			panic(err)
+		}
+	}
+	return p.current
+}
+
+// songTokens splits a line of notes on spaces, keeping bracketed chords together.
+func songTokens(line string) []string {
+	var tokens []string
+	for _, f := range strings.Fields(line) {
+		n := len(tokens)
+		if n > 0 && strings.Count(tokens[n-1], "[") > strings.Count(tokens[n-1], "]") {
+			tokens[n-1] += " " + f
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+func (p *songParser) token(tok string) error {
+	v := p.voice()
+	if tok == "|" || tok == "||" || tok == "|]" {
+		return nil
+	}
+	if strings.HasPrefix(tok, "(") {
+		return v.startTuplet(tok)
+	}
+
+	tie := strings.HasSuffix(tok, "~")
+	tok = strings.TrimSuffix(tok, "~")
+	dots := len(tok) - len(strings.TrimRight(tok, "."))
+	tok = tok[:len(tok)-dots]
+
+	var body, duration string
+	switch {
+	case strings.HasPrefix(tok, "["):
+		end := strings.IndexByte(tok, ']')
+		if end == -1 {
+			return fmt.Errorf("unclosed chord %q", tok)
+		}
+		body, duration = tok[:end+1], tok[end+1:]
+	case strings.HasPrefix(tok, "\""):
+		end := strings.IndexByte(tok[1:], '"')
+		if end == -1 {
+			return fmt.Errorf("unclosed chord symbol %q", tok)
+		}
+		end += 2
+		for end < len(tok) && tok[end] >= '0' && tok[end] <= '9' {
+			end++
+		}
+		body, duration = tok[:end], tok[end:]
+	default:
+		body, duration, _ = strings.Cut(tok, "/")
+		if duration != "" {
+			duration = "/" + duration
+		}
+	}
+
+	if duration != "" {
+		if !strings.HasPrefix(duration, "/") {
+			return fmt.Errorf("bad duration %q", tok)
+		}
+		division, err := strconv.Atoi(duration[1:])
+		if err != nil || division <= 0 {
+			return fmt.Errorf("bad duration %q", tok)
+		}
+		v.duration = WholeNote / float64(division)
+	}
+	beats := v.duration
+	for i, dot := 0, v.duration/2; i < dots; i, dot = i+1, dot/2 {
+		beats += dot
+	}
+	if v.tupletNotes > 0 {
+		beats *= v.tuplet
+		v.tupletNotes--
+	}
+
+	pitches, err := v.pitches(body)
+	if err != nil {
+		return err
+	}
+	p.started = true
+	track := &p.song.Tracks[v.track]
+	var added []int
+	for _, pitch := range pitches {
+		if i, ok := v.tiedTo(track, pitch); ok {
+			track.Notes[i].Duration += beats
+			added = append(added, i)
+			continue
+		}
+		track.Add(Note{Pitch: pitch, Start: v.beat, Duration: beats})
+		added = append(added, len(track.Notes)-1)
+	}
+	v.tied = nil
+	if tie {
+		v.tied = added
+	}
+	v.beat += beats
+	return nil
+}
+
+// tiedTo returns the index of a note tied to pitch, if there is one.
+func (v *songVoice) tiedTo(track *Track, pitch Pitch) (int, bool) {
+	for _, i := range v.tied {
+		if track.Notes[i].Pitch == pitch {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (v *songVoice) startTuplet(tok string) error {
+	var notes, time int
+	if _, err := fmt.Sscanf(tok, "(%d:%d", &notes, &time); err != nil {
+		if _, err := fmt.Sscanf(tok, "(%d", &notes); err != nil {
+			return fmt.Errorf("bad tuplet %q", tok)
+		}
+		// the usual number of notes a tuplet replaces
+		switch notes {
+		case 2, 4:
+			time = 3
+		case 3, 6:
+			time = notes * 2 / 3
+		default:
+			time = 1
+			for time*2 < notes {
+				time *= 2
+			}
+		}
+	}
+	if notes <= 0 || time <= 0 {
+		return fmt.Errorf("bad tuplet %q", tok)
+	}
+	v.tuplet = float64(time) / float64(notes)
+	v.tupletNotes = notes
+	return nil
+}
+
+// pitches returns the pitches of a note, chord, chord symbol, or rest.
+func (v *songVoice) pitches(body string) ([]Pitch, error) {
+	switch {
+	case body == "r":
+		return nil, nil
+	case strings.HasPrefix(body, "["):
+		var ps []Pitch
+		for _, name := range strings.Fields(strings.Trim(body, "[]")) {
+			pitch, err := v.pitch(name)
+			if err != nil {
+				return nil, err
+			}
+			ps = append(ps, pitch)
+		}
+		return ps, nil
+	case strings.HasPrefix(body, "\""):
+		end := strings.LastIndexByte(body, '"')
+		cs, err := ParseChord(body[1:end])
+		if err != nil {
+			return nil, err
+		}
+		octave := 4
+		if body[end+1:] != "" {
+			octave, _ = strconv.Atoi(body[end+1:])
+		}
+		return cs.Pitches(octave), nil
+	}
+	pitch, err := v.pitch(body)
+	if err != nil {
+		return nil, err
+	}
+	return []Pitch{pitch}, nil
+}
+
+// pitch parses a pitch name, using the octave of the previous pitch if name has none.
+func (v *songVoice) pitch(name string) (Pitch, error) {
+	_, rest, ok := parseLetterName(name)
+	if !ok {
+		return Rest, fmt.Errorf("bad pitch %q", name)
+	}
+	letters := name[:len(name)-len(rest)]
+	centsOnly := strings.HasSuffix(rest, "c") && strings.Count(rest, "+")+strings.Count(rest, "-") == 1
+	if rest == "" || rest[0] == '+' || (rest[0] == '-' && centsOnly) {
+		rest = strconv.Itoa(v.octave) + rest
+	} else {
+		octaveEnd := strings.IndexAny(rest[1:], "+-") + 1
+		if octaveEnd == 0 {
+			octaveEnd = len(rest)
+		}
+		octave, err := strconv.Atoi(rest[:octaveEnd])
+		if err != nil {
+			return Rest, fmt.Errorf("bad pitch %q", name)
+		}
+		v.octave = octave
+	}
+	return ParsePitch(letters + rest)
+}
@@ -0,0 +1,122 @@
+package daw
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// songNote is a note as its midi note, start and duration.
+type songNote struct {
+	midi            int
+	start, duration float64
+}
+
+func checkTrack(t *testing.T, track Track, want []songNote) {
+	t.Helper()
+	if len(track.Notes) != len(want) {
+		t.Errorf("%v: got %v notes, want %v", track.Name, len(track.Notes), len(want))
+		return
+	}
+	for i, n := range track.Notes {
+		w := want[i]
+		if MIDINote(n.Pitch) != w.midi || math.Abs(n.Start-w.start) > 1e-9 || math.Abs(n.Duration-w.duration) > 1e-9 {
+			t.Errorf("%v: note %v is %v %v %v, want %v", track.Name, i, MIDINote(n.Pitch), n.Start, n.Duration, w)
+		}
+	}
+}
+
+func TestParseSong(t *testing.T) {
+	song, err := ParseSong(`
+% a short song
+tempo: 90
+time: 3/4
+key: D major
+
+voice: melody
+C4/4 D E/8 F. G/16 | r/4 A4~ A/2 % ties and rests
+voice: bass
+[C3 G3]/2 "Am"3/4
+voice: melody
+(3 B4/8 C5 D (3:2 E/16 F G |
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.BPM != 90 || song.TimeSignature != (TimeSignature{Beats: 3, Unit: 4}) || len(song.TempoChanges) != 0 {
+		t.Errorf("got tempo %v and time %v, changes %v", song.BPM, song.TimeSignature, song.TempoChanges)
+	}
+	if !reflect.DeepEqual(song.Key, Key{Start: D4, Pattern: MajorKey}) {
+		t.Errorf("got key %+v", song.Key)
+	}
+	if len(song.Tracks) != 2 || song.Tracks[0].Name != "melody" || song.Tracks[1].Name != "bass" {
+		t.Fatalf("got tracks %+v", song.Tracks)
+	}
+	third := 1. / 3
+	checkTrack(t, song.Tracks[0], []songNote{
+		{60, 0, 1},
+		{62, 1, 1},
+		{64, 2, .5},
+		{65, 2.5, .75},
+		{67, 3.25, .25},
+		{69, 4.5, 3},
+		{71, 7.5, third},
+		{72, 7.5 + third, third},
+		{74, 7.5 + 2*third, third},
+		{76, 8.5, third / 2},
+		{77, 8.5 + third/2, third / 2},
+		{79, 8.5 + third, third / 2},
+	})
+	checkTrack(t, song.Tracks[1], []songNote{
+		{48, 0, 2},
+		{55, 0, 2},
+		{57, 2, 1},
+		{60, 2, 1},
+		{64, 2, 1},
+	})
+}
+
+func TestParseSongTempoChanges(t *testing.T) {
+	song, err := ParseSong(`
+voice: a
+C4/1
+tempo: 60
+C4/1
+voice: b
+C4/2
+tempo: 100
+C4/2
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// changes written in later voices are sorted in with the rest
+	want := []TempoChange{{Beat: 2, BPM: 100}, {Beat: 4, BPM: 60}}
+	if song.BPM != 120 || !reflect.DeepEqual(song.TempoChanges, want) {
+		t.Errorf("got %v %v, want 120 %v", song.BPM, song.TempoChanges, want)
+	}
+}
+
+func TestParseSongInvalid(t *testing.T) {
+	for _, text := range []string{
+		"tempo: fast",
+		"tempo: -1",
+		"time: 3",
+		"key: H major",
+		"key: C blues",
+		"swing: 60",
+		"C4/0",
+		"C4/x",
+		"C4q",
+		"X4",
+		"[C4 E4",
+		`"Am`,
+		`"Hm"`,
+		"(x",
+	} {
+		if _, err := ParseSong(text); !errors.Is(err, ErrInvalidSongText) {
+			t.Errorf("%q: got error %v, want %v", text, err, ErrInvalidSongText)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package daw
+
+import "math"
+
+// A Tuning decides how far apart the steps of a scale are. Pitches, Keys and Chords count their
+// intervals in Steps; a Tuning turns a count of steps above a root into a frequency ratio.
+// The named pitches and Pitch.Up use twelve tone equal temperament.
+type Tuning interface {
+	// Ratio returns the frequency of the pitch s steps above a root, divided by the root's
+	// frequency. s may be negative.
+	Ratio(s Step) float64
+}
+
+// An EDO is an equal division of the octave: each of its steps has the same ratio, and that many
+// steps make an octave.
+type EDO int
+
+// TwelveTET is twelve tone equal temperament, the tuning of the named pitches.
+const TwelveTET EDO = 12
+
+func (e EDO) Ratio(s Step) float64 {
+	return math.Exp2(float64(s) / float64(e))
+}
+
+// A RatioTuning is a repeating list of frequency ratios above a root, in the manner of a Scala
+// scale. Its last ratio is the period the scale repeats at, usually 2 for an octave.
+type RatioTuning struct {
+	Description string
+	// Ratios holds the ratio of step 1, step 2, and so on up to the period. Step 0 is always 1.
+	Ratios []float64
+}
+
+func (rt RatioTuning) Ratio(s Step) float64 {
+	n := len(rt.Ratios)
+	if n == 0 {
+		return 1
+	}
+	periods := floorDiv(int(s), n)
+	degree := int(s) - periods*n
+	r := math.Pow(rt.Ratios[n-1], float64(periods))
+	if degree != 0 {
+		r *= rt.Ratios[degree-1]
+	}
+	return r
+}
+
+// Size returns how many steps this tuning has before it repeats.
+func (rt RatioTuning) Size() int {
+	return len(rt.Ratios)
+}
+
+var (
+	// JustIntonation is five limit just intonation: twelve steps whose intervals above the root
+	// are small whole number ratios, so chords built on the root are perfectly in tune.
+	JustIntonation = RatioTuning{
+		Description: "5-limit just intonation",
+		Ratios: []float64{
+			16. / 15, 9. / 8, 6. / 5, 5. / 4, 4. / 3, 45. / 32,
+			3. / 2, 8. / 5, 5. / 3, 9. / 5, 15. / 8, 2,
+		},
+	}
+	// Pythagorean tunes twelve steps as a chain of perfect 3/2 fifths, from five fifths below the
+	// root (a minor second) to six above it (an augmented fourth).
+	Pythagorean = chainOfFifths("Pythagorean", 3./2, -5)
+	// QuarterCommaMeantone narrows each fifth so that four of them make a pure 5/4 major third.
+	QuarterCommaMeantone = Meantone(math.Pow(5, .25))
+)
+
+// Meantone returns a twelve step tuning made of a chain of fifths of the given ratio, from three
+// fifths below the root (a minor third) to eight above it (an augmented fifth).
+func Meantone(fifth float64) RatioTuning {
+	return chainOfFifths("meantone", fifth, -3)
+}
+
+// chainOfFifths builds a twelve step octave tuning by stacking fifths from lowest fifths below
+// the root, reducing each into the octave above the root.
+func chainOfFifths(description string, fifth float64, lowest int) RatioTuning {
+	ratios := make([]float64, 12)
+	ratios[11] = 2
+	for f := lowest; f < lowest+12; f++ {
+		r := math.Pow(fifth, float64(f))
+		r /= math.Exp2(math.Floor(math.Log2(r)))
+		degree := ((f*int(Perfect5))%12 + 12) % 12
+		if degree != 0 {
+			ratios[degree-1] = r
+		}
+	}
+	return RatioTuning{
+		Description: description,
+		Ratios:      ratios,
+	}
+}
+
+// Tuned returns the pitch s steps above p in tuning t, or in twelve tone equal temperament if t
+// is nil.
+func (p Pitch) Tuned(t Tuning, s Step) Pitch {
+	if t == nil {
+		return p.Up(s)
+	}
+	return p * Pitch(t.Ratio(s))
+}
@@ -0,0 +1,46 @@
+package daw
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTuningRatios(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning Tuning
+		step   Step
+		want   float64
+	}{
+		{"12 tet octave", TwelveTET, 12, 2},
+		{"12 tet fifth", TwelveTET, 7, math.Exp2(7. / 12)},
+		{"19 edo octave", EDO(19), 19, 2},
+		{"19 edo below", EDO(19), -19, .5},
+		{"just third", JustIntonation, 4, 5. / 4},
+		{"just fifth", JustIntonation, 7, 3. / 2},
+		{"just unison", JustIntonation, 0, 1},
+		{"just octave and a fifth", JustIntonation, 19, 3},
+		{"just fifth below", JustIntonation, -7, 2. / 3},
+		{"pythagorean fifth", Pythagorean, 7, 3. / 2},
+		{"pythagorean third", Pythagorean, 4, 81. / 64},
+		{"pythagorean minor second", Pythagorean, 1, 256. / 243},
+		{"pythagorean augmented fourth", Pythagorean, 6, 729. / 512},
+		{"meantone third", QuarterCommaMeantone, 4, 5. / 4},
+		{"meantone fifth", QuarterCommaMeantone, 7, math.Pow(5, .25)},
+		{"empty", RatioTuning{}, 5, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.tuning.Ratio(tt.step); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTuned(t *testing.T) {
+	if got := A4.Tuned(nil, 3); got != A4.Up(3) {
+		t.Errorf("no tuning: got %v, want %v", got, A4.Up(3))
+	}
+	if got := A4.Tuned(JustIntonation, 7); math.Abs(float64(got)-660) > 1e-9 {
+		t.Errorf("just fifth above A4: got %v, want 660", got)
+	}
+}
@@ -0,0 +1,173 @@
+package daw
+
+import (
+	"math"
+	"sort"
+)
+
+// The functions below arrange the pitches of a chord, from lowest to highest, into different
+// voicings. They move pitches by octaves, so they assume a tuning which repeats at the octave.
+
+// sortedPitches returns a sorted copy of ps.
+func sortedPitches(ps []Pitch) []Pitch {
+	out := make([]Pitch, len(ps))
+	copy(out, ps)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Invert moves the lowest pitch of ps up an octave n times, or if n is negative, moves the highest
+// pitch down an octave -n times.
+func Invert(ps []Pitch, n int) []Pitch {
+	out := sortedPitches(ps)
+	if len(out) == 0 {
+		return out
+	}
+	// in chords wider than an octave, a moved pitch may not become the highest or lowest
+	for ; n > 0; n-- {
+		out = sortedPitches(append(out[1:], out[0]*2))
+	}
+	for ; n < 0; n++ {
+		out = sortedPitches(append([]Pitch{out[len(out)-1] / 2}, out[:len(out)-1]...))
+	}
+	return out
+}
+
+// Inversion returns this chord built on root, then inverted n times.
+func (c Chord) Inversion(root Pitch, n int) []Pitch {
+	return Invert(c.WithRoot(root), n)
+}
+
+// Drop moves voices of ps down an octave, counting voices from the highest as 1. Drop(ps, 2) is a
+// drop 2 voicing, and Drop(ps, 2, 4) a drop 2 and 4 voicing.
+func Drop(ps []Pitch, voices ...int) []Pitch {
+	out := sortedPitches(ps)
+	for _, v := range voices {
+		if i := len(out) - v; i >= 0 && i < len(out) {
+			out[i] /= 2
+		}
+	}
+	return sortedPitches(out)
+}
+
+// Open moves every other voice of ps above the lowest up an octave, so that a close C E G
+// becomes C G E.
+func Open(ps []Pitch) []Pitch {
+	out := sortedPitches(ps)
+	for i := 1; i < len(out); i += 2 {
+		out[i] *= 2
+	}
+	return sortedPitches(out)
+}
+
+// Spread moves the lowest voice of ps down an octave and opens the rest, leaving a wide gap
+// between the bass and the upper voices.
+func Spread(ps []Pitch) []Pitch {
+	out := sortedPitches(ps)
+	if len(out) == 0 {
+		return out
+	}
+	return append([]Pitch{out[0] / 2}, Open(out[1:])...)
+}
+
+// InRange moves each pitch of ps by octaves until it is between low and high. Pitches which cannot
+// fit in a range smaller than an octave are left as close to it as possible.
+func InRange(ps []Pitch, low, high Pitch) []Pitch {
+	out := make([]Pitch, len(ps))
+	for i, p := range ps {
+		for p < low && p > 0 {
+			p *= 2
+		}
+		for p > high && p/2 >= low {
+			p /= 2
+		}
+		out[i] = p
+	}
+	return sortedPitches(out)
+}
+
+// semitonesBetween returns the distance between two pitches in half steps.
+func semitonesBetween(a, b Pitch) float64 {
+	return math.Abs(12 * math.Log2(float64(a)/float64(b)))
+}
+
+// voiceMotion returns how far the voices of prev move to reach next, both sorted. Chords with the
+// same number of voices are compared voice by voice; otherwise each pitch is compared to the
+// nearest pitch of the other chord.
+func voiceMotion(prev, next []Pitch) float64 {
+	var motion float64
+	if len(prev) == len(next) {
+		for i := range prev {
+			motion += semitonesBetween(prev[i], next[i])
+		}
+		return motion
+	}
+	nearest := func(p Pitch, ps []Pitch) float64 {
+		d := math.Inf(1)
+		for _, q := range ps {
+			d = math.Min(d, semitonesBetween(p, q))
+		}
+		return d
+	}
+	for _, p := range prev {
+		motion += nearest(p, next)
+	}
+	for _, p := range next {
+		motion += nearest(p, prev)
+	}
+	return motion
+}
+
+// VoiceLead returns the voicing of next, moving its pitches by octaves, whose voices move the
+// least in total from prev. Voicings are considered within an octave of prev's range.
+func VoiceLead(prev, next []Pitch) []Pitch {
+	prev = sortedPitches(prev)
+	if len(prev) == 0 || len(next) == 0 {
+		return sortedPitches(next)
+	}
+	low, high := prev[0]/2, prev[len(prev)-1]*2
+	// every octave each pitch of next could be placed in
+	options := make([][]Pitch, len(next))
+	for i, p := range next {
+		p = InRange([]Pitch{p}, low, low*2)[0]
+		for ; p <= high; p *= 2 {
+			options[i] = append(options[i], p)
+		}
+		if len(options[i]) == 0 {
+			options[i] = []Pitch{p}
+		}
+	}
+	var best []Pitch
+	bestMotion := math.Inf(1)
+	candidate := make([]Pitch, len(next))
+	var search func(i int)
+	search = func(i int) {
+		if i == len(next) {
+			voicing := sortedPitches(candidate)
+			if m := voiceMotion(prev, voicing); m < bestMotion {
+				best, bestMotion = voicing, m
+			}
+			return
+		}
+		for _, p := range options[i] {
+			candidate[i] = p
+			search(i + 1)
+		}
+	}
+	search(0)
+	return best
+}
+
+// VoiceLeadProgression voices each chord of a progression after the first with VoiceLead from the
+// chord before it.
+func VoiceLeadProgression(chords [][]Pitch) [][]Pitch {
+	out := make([][]Pitch, len(chords))
+	for i, c := range chords {
+		if i == 0 {
+			out[i] = sortedPitches(c)
+			continue
+		}
+		out[i] = VoiceLead(out[i-1], c)
+	}
+	return out
+}
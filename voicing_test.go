@@ -0,0 +1,72 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVoicings(t *testing.T) {
+	c := MajorTriad.WithRoot(C4)
+	cmaj7 := MajorSeventh.WithRoot(C4)
+	tests := []struct {
+		name    string
+		voicing []Pitch
+		want    []int
+	}{
+		{"first inversion", Invert(cmaj7, 1), []int{64, 67, 71, 72}},
+		{"second inversion", MajorSeventh.Inversion(C4, 2), []int{67, 71, 72, 76}},
+		{"inverted down", Invert(cmaj7, -1), []int{59, 60, 64, 67}},
+		// the moved root lands inside a chord wider than an octave
+		{"ninth inverted", Invert(Chord{Major3, Perfect5, Major7, Octave + Major2}.WithRoot(C4), 1), []int{64, 67, 71, 72, 74}},
+		{"unsorted", Invert([]Pitch{G4, C4, E4}, 1), []int{64, 67, 72}},
+		{"drop 2", Drop(cmaj7, 2), []int{55, 60, 64, 71}},
+		{"drop 2 and 4", Drop(cmaj7, 2, 4), []int{48, 55, 64, 71}},
+		{"drop past the bass", Drop(c, 4), []int{60, 64, 67}},
+		{"open", Open(c), []int{60, 67, 76}},
+		{"open seventh", Open(cmaj7), []int{60, 67, 76, 83}},
+		{"spread", Spread(c), []int{48, 64, 79}},
+		{"in range", InRange([]Pitch{C2, E6, G3}, C4, C5), []int{60, 64, 67}},
+		// E can't fit between C and D, so it stays where it is
+		{"narrow range", InRange([]Pitch{E4}, C4, D4), []int{64}},
+		{"empty", Invert(nil, 1), []int{}},
+	}
+	for _, tt := range tests {
+		if got := midiNotes(tt.voicing); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVoiceLead(t *testing.T) {
+	c := MajorTriad.WithRoot(C4)
+	tests := []struct {
+		name       string
+		prev, next []Pitch
+		want       []int
+	}{
+		{"I to IV", c, MajorTriad.WithRoot(F4), []int{60, 65, 69}},
+		{"I to V", c, MajorTriad.WithRoot(G4), []int{59, 62, 67}},
+		{"from far away", c, MajorTriad.WithRoot(G2), []int{59, 62, 67}},
+		{"same chord", c, MajorTriad.WithRoot(C5), []int{60, 64, 67}},
+		{"no previous chord", nil, []Pitch{G4, C4}, []int{60, 67}},
+	}
+	for _, tt := range tests {
+		if got := midiNotes(VoiceLead(tt.prev, tt.next)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVoiceLeadProgression(t *testing.T) {
+	k := Key{Start: C4, Pattern: MajorKey}
+	chords, err := k.Progression("I-vi-IV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]int{{60, 64, 67}, {60, 64, 69}, {60, 65, 69}}
+	for i, ps := range VoiceLeadProgression(chords) {
+		if got := midiNotes(ps); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("chord %v: got %v, want %v", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package daw
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio"
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	wavHeaderSize = 44
+	// offsets of the sizes which can only be known once all data has been written
+	wavRIFFSizeOffset = 4
+	wavDataSizeOffset = 40
+)
+
+// ErrUnsupportedWAVFormat is returned when asked to write a WAV file for a format it can't hold,
+// or to read one holding samples it can't decode.
+var ErrUnsupportedWAVFormat = errors.New("unsupported wav format")
+
+// ErrInvalidWAV is returned when reading data which is not a well formed WAV file.
+var ErrInvalidWAV = errors.New("invalid wav file")
+
+// wavSubFormatSuffix follows the format tag in the sub format GUID of an extensible format chunk.
+const wavSubFormatSuffix = "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71"
+
+// readWAV reads the format and samples of a WAV file, walking its chunks to find them so that files
+// with extensible format chunks or extra chunks, like LIST or bext, can be read.
+func readWAV(r io.Reader) (pcm.Format, Encoding, []byte, error) {
+	var format pcm.Format
+	var head [12]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return format, 0, nil, fmt.Errorf("%w: %v", ErrInvalidWAV, err)
+	}
+	if string(head[0:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+		return format, 0, nil, fmt.Errorf("%w: missing RIFF WAVE header", ErrInvalidWAV)
+	}
+	var enc Encoding
+	sawFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return format, 0, nil, fmt.Errorf("%w: missing data chunk: %v", ErrInvalidWAV, err)
+		}
+		id := string(chunk[:4])
+		size := binary.LittleEndian.Uint32(chunk[4:])
+		switch id {
+		case "fmt ":
+			// format chunks are small; don't trust the size to allocate
+			if size < 16 || size > 1024 {
+				return format, 0, nil, fmt.Errorf("%w: %v byte format chunk", ErrInvalidWAV, size)
+			}
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return format, 0, nil, fmt.Errorf("%w: %v", ErrInvalidWAV, err)
+			}
+			tag := binary.LittleEndian.Uint16(data[0:])
+			format = pcm.Format{
+				Channels:   binary.LittleEndian.Uint16(data[2:]),
+				SampleRate: binary.LittleEndian.Uint32(data[4:]),
+				Bits:       binary.LittleEndian.Uint16(data[14:]),
+			}
+			if tag == wavFormatExtensible && size >= 40 && string(data[26:40]) == wavSubFormatSuffix {
+				tag = binary.LittleEndian.Uint16(data[24:])
+			}
+			switch {
+			case tag == wavFormatPCM && (format.Bits == 8 || format.Bits == 16 || format.Bits == 24 || format.Bits == 32):
+				enc = EncodingInt
+			case tag == wavFormatFloat && (format.Bits == 32 || format.Bits == 64):
+				enc = EncodingFloat
+			default:
+				return format, 0, nil, fmt.Errorf("%w: format %#x, %v bit", ErrUnsupportedWAVFormat, tag, format.Bits)
+			}
+			if format.Channels == 0 || format.SampleRate == 0 {
+				return format, 0, nil, fmt.Errorf("%w: %v channels at %v hz", ErrUnsupportedWAVFormat, format.Channels, format.SampleRate)
+			}
+			sawFormat = true
+		case "data":
+			if !sawFormat {
+				return format, 0, nil, fmt.Errorf("%w: data before format", ErrInvalidWAV)
+			}
+			// files still being written may not have their data size set, so read whatever is there
+			data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+			if err != nil {
+				return format, 0, nil, fmt.Errorf("%w: %v", ErrInvalidWAV, err)
+			}
+			return format, enc, data[:len(data)-len(data)%format.SampleSize()], nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+				return format, 0, nil, fmt.Errorf("%w: %v chunk: %v", ErrInvalidWAV, id, err)
+			}
+		}
+	}
+}
+
+func wavHeader(format pcm.Format, enc Encoding, dataSize uint32) ([]byte, error) {
+	tag := uint16(wavFormatPCM)
+	switch {
+	case enc == EncodingInt && (format.Bits == 8 || format.Bits == 16 || format.Bits == 24 || format.Bits == 32):
+	case enc == EncodingFloat && (format.Bits == 32 || format.Bits == 64):
+		tag = wavFormatFloat
+	default:
+		return nil, fmt.Errorf("%w: %v bit encoding %v", ErrUnsupportedWAVFormat, format.Bits, enc)
+	}
+	if format.Channels == 0 || format.SampleRate == 0 {
+		return nil, fmt.Errorf("%w: %v channels at %v hz", ErrUnsupportedWAVFormat, format.Channels, format.SampleRate)
+	}
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	// odd sized data is followed by a pad byte
+	binary.LittleEndian.PutUint32(h[wavRIFFSizeOffset:], wavHeaderSize-8+dataSize+dataSize%2)
+	copy(h[8:], "WAVE")
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], tag)
+	binary.LittleEndian.PutUint16(h[22:], format.Channels)
+	binary.LittleEndian.PutUint32(h[24:], format.SampleRate)
+	binary.LittleEndian.PutUint32(h[28:], format.BytesPerSecond())
+	binary.LittleEndian.PutUint16(h[32:], uint16(format.SampleSize()))
+	binary.LittleEndian.PutUint16(h[34:], format.Bits)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[wavDataSizeOffset:], dataSize)
+	return h, nil
+}
+
+// WriteWAV writes duration worth of audio from r to w as a WAV file, using r's format and encoding.
+// If r runs out of data first, the remainder is filled with silence.
+func WriteWAV(w io.Writer, r pcm.Reader, duration time.Duration) error {
+	format := r.PCMFormat()
+	enc := EncodingOf(r)
+	size := format.SampleSize()
+	frames := int(duration.Seconds() * float64(format.SampleRate))
+	h, err := wavHeader(format, enc, uint32(frames*size))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(h); err != nil {
+		return err
+	}
+	silence := make([]float64, format.Channels)
+	buf := make([]byte, int(float64(format.BytesPerSecond())*audio.WriterBufferLengthInSeconds)/size*size)
+	var eof bool
+	for remaining := frames * size; remaining > 0; remaining -= len(buf) {
+		if len(buf) > remaining {
+			buf = buf[:remaining]
+		}
+		var n int
+		if !eof {
+			n, err = audio.ReadFull(r, buf)
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+		for i := n / size * size; i < len(buf); i += size {
+			encodeFrame(buf[i:], format, enc, silence)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	if frames*size%2 == 1 {
+		_, err = w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+var _ Writer = &WAVWriter{}
+
+// A WAVWriter is a Writer which saves audio to a WAV file. The file's header is kept up to date
+// as data is written, so the file is valid even if it is never closed. Closing it pads the data to
+// an even length, as RIFF files require.
+type WAVWriter struct {
+	pcm.Format
+	Encoding
+
+	mu       sync.Mutex
+	ws       io.WriteSeeker
+	dataSize uint32
+	sizeBuf  [4]byte
+	closed   bool
+}
+
+// NewWAVWriter writes a WAV header to ws and returns a writer to append audio to it.
+func NewWAVWriter(ws io.WriteSeeker, format pcm.Format, enc Encoding) (*WAVWriter, error) {
+	h, err := wavHeader(format, enc, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ws.Write(h); err != nil {
+		return nil, err
+	}
+	return &WAVWriter{
+		Format:   format,
+		Encoding: enc,
+		ws:       ws,
+	}, nil
+}
+
+// CreateWAV creates or truncates the named file and returns a WAVWriter to it.
+func CreateWAV(name string, format pcm.Format, enc Encoding) (*WAVWriter, error) {
+	fl, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWAVWriter(fl, format, enc)
+	if err != nil {
+		fl.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (ww *WAVWriter) PCMFormat() pcm.Format {
+	return ww.Format
+}
+
+func (ww *WAVWriter) WritePCM(b []byte) (n int, err error) {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	n, err = ww.ws.Write(b)
+	ww.dataSize += uint32(n)
+	if err != nil {
+		return n, err
+	}
+	return n, ww.writeSizes(0)
+}
+
+// Write is equivalent to WritePCM, allowing a WAVWriter to be the destination of an OfflineWriter.
+func (ww *WAVWriter) Write(b []byte) (n int, err error) {
+	return ww.WritePCM(b)
+}
+
+// writeSizes updates the header with the size of the data written so far and pad bytes after it.
+func (ww *WAVWriter) writeSizes(pad uint32) error {
+	if err := ww.writeUint32At(wavRIFFSizeOffset, wavHeaderSize-8+ww.dataSize+pad); err != nil {
+		return err
+	}
+	if err := ww.writeUint32At(wavDataSizeOffset, ww.dataSize); err != nil {
+		return err
+	}
+	_, err := ww.ws.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (ww *WAVWriter) writeUint32At(offset int64, v uint32) error {
+	if _, err := ww.ws.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(ww.sizeBuf[:], v)
+	_, err := ww.ws.Write(ww.sizeBuf[:])
+	return err
+}
+
+// Close pads the data to an even length, and closes the underlying file, if it is an io.Closer.
+func (ww *WAVWriter) Close() error {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	if ww.closed {
+		return nil
+	}
+	ww.closed = true
+	var err error
+	if ww.dataSize%2 == 1 {
+		if _, err = ww.ws.Write([]byte{0}); err == nil {
+			err = ww.writeSizes(1)
+		}
+	}
+	if cl, ok := ww.ws.(io.Closer); ok {
+		if cerr := cl.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
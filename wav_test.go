@@ -0,0 +1,112 @@
+package daw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oakmound/oak/v4/audio/pcm"
+)
+
+// checkWAV checks that data is a WAV file of format and enc holding want, padded as RIFF requires.
+func checkWAV(t *testing.T, data []byte, format pcm.Format, enc Encoding, want []byte) {
+	t.Helper()
+	if riffSize := binary.LittleEndian.Uint32(data[4:]); int(riffSize) != len(data)-8 {
+		t.Errorf("RIFF size is %v, want %v", riffSize, len(data)-8)
+	}
+	if len(data)%2 != 0 {
+		t.Errorf("file is %v bytes, want an even length", len(data))
+	}
+	gotFormat, gotEnc, got, err := readWAV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotFormat != format || gotEnc != enc {
+		t.Errorf("got %+v %v, want %+v %v", gotFormat, gotEnc, format, enc)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got data % x, want % x", got, want)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	tests := []struct {
+		name   string
+		format pcm.Format
+		enc    Encoding
+		data   []byte
+		want   []byte
+	}{
+		{
+			"odd length is padded",
+			pcm.Format{SampleRate: 1000, Channels: 1, Bits: 8},
+			EncodingInt,
+			[]byte{1, 2, 3, 4, 5},
+			[]byte{1, 2, 3, 4, 5},
+		},
+		{
+			"short input is filled with silence",
+			pcm.Format{SampleRate: 1000, Channels: 2, Bits: 16},
+			EncodingInt,
+			[]byte{1, 2, 3, 4, 5, 6, 7, 8},
+			append([]byte{1, 2, 3, 4, 5, 6, 7, 8}, make([]byte, 12)...),
+		},
+		{
+			"float",
+			pcm.Format{SampleRate: 1000, Channels: 1, Bits: 32},
+			EncodingFloat,
+			[]byte{0, 0, 0x80, 0x3F, 0, 0, 0, 0xBF},
+			[]byte{0, 0, 0x80, 0x3F, 0, 0, 0, 0xBF},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := struct {
+				pcm.Reader
+				Encoding
+			}{&pcm.IOReader{Format: tt.format, Reader: bytes.NewReader(tt.data)}, tt.enc}
+			duration := time.Duration(len(tt.want)/tt.format.SampleSize()) * time.Second / time.Duration(tt.format.SampleRate)
+			var buf bytes.Buffer
+			if err := WriteWAV(&buf, r, duration); err != nil {
+				t.Fatal(err)
+			}
+			checkWAV(t, buf.Bytes(), tt.format, tt.enc, tt.want)
+		})
+	}
+}
+
+func TestWAVWriter(t *testing.T) {
+	format := pcm.Format{SampleRate: 1000, Channels: 1, Bits: 8}
+	name := filepath.Join(t.TempDir(), "out.wav")
+	w, err := CreateWAV(name, format, EncodingInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{{1, 2}, {3}} {
+		if _, err := w.WritePCM(b); err != nil {
+			t.Fatal(err)
+		}
+		// the header is kept up to date, so the file is readable before it is closed
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, got, err := readWAV(bytes.NewReader(data)); err != nil || len(got) != len(data)-wavHeaderSize {
+			t.Errorf("before closing: read %v bytes of data, %v", len(got), err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkWAV(t, data, format, EncodingInt, []byte{1, 2, 3})
+}
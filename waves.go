@@ -3,9 +3,109 @@ package daw
 import "math"
 
 var SinFunc = func(pr *PitchReader) float64 {
-	return math.Sin(ModPhase(*pr.Pitch, pr.Phase, pr.Format.SampleRate)) * pr.Volume
+	return math.Sin(pr.Phase) * pr.Volume
 }
 
 var SawFunc = func(pr *PitchReader) float64 {
-	return pr.Volume - (pr.Volume / math.Pi * ModPhase(*pr.Pitch, pr.Phase, pr.SampleRate))
+	return pr.Volume - (pr.Volume / math.Pi * pr.Phase)
+}
+
+var SquareFunc = PulseFunc(.5)
+
+// PulseFunc returns a wave which is high for width of each cycle, and low for the rest.
+func PulseFunc(width float64) func(*PitchReader) float64 {
+	return func(pr *PitchReader) float64 {
+		if cyclePosition(pr) < width {
+			return pr.Volume
+		}
+		return -pr.Volume
+	}
+}
+
+var TriangleFunc = func(pr *PitchReader) float64 {
+	p := pr.Phase
+	m := p * (2 * pr.Volume / math.Pi)
+	if math.Sin(p) > 0 {
+		return -pr.Volume + m
+	}
+	return 3*pr.Volume - m
+}
+
+// The naive waves above jump or turn instantly, which requires frequencies far above what
+// can be represented at a sample rate. Those frequencies alias back down as inharmonic noise,
+// which is clearly audible at high pitches. The band limited waves below smooth each jump and
+// turn over the samples around it with polynomial approximations (PolyBLEP and PolyBLAMP).
+
+// BandLimitedSawFunc is SawFunc without aliasing.
+var BandLimitedSawFunc = func(pr *PitchReader) float64 {
+	t, dt := cyclePosition(pr), cycleIncrement(pr)
+	return pr.Volume * (1 - 2*t + polyBLEP(t, dt))
+}
+
+// BandLimitedSquareFunc is SquareFunc without aliasing.
+var BandLimitedSquareFunc = BandLimitedPulseFunc(.5)
+
+// BandLimitedPulseFunc is PulseFunc without aliasing.
+func BandLimitedPulseFunc(width float64) func(*PitchReader) float64 {
+	return func(pr *PitchReader) float64 {
+		t, dt := cyclePosition(pr), cycleIncrement(pr)
+		v := -1.0
+		if t < width {
+			v = 1
+		}
+		v += polyBLEP(t, dt)
+		v -= polyBLEP(math.Mod(t+1-width, 1), dt)
+		return v * pr.Volume
+	}
+}
+
+// BandLimitedTriangleFunc is TriangleFunc without aliasing.
+var BandLimitedTriangleFunc = func(pr *PitchReader) float64 {
+	t, dt := cyclePosition(pr), cycleIncrement(pr)
+	v := 4*t - 1
+	if t >= .5 {
+		v = 3 - 4*t
+	}
+	// the slope changes by 8 per cycle, or 8*dt per sample, at each corner
+	v += 4 * dt * polyBLAMP(t, dt)
+	v -= 4 * dt * polyBLAMP(math.Mod(t+.5, 1), dt)
+	return v * pr.Volume
+}
+
+// cyclePosition returns how far through its current cycle pr is, from 0 to 1.
+func cyclePosition(pr *PitchReader) float64 {
+	return pr.Phase / (2 * math.Pi)
+}
+
+// cycleIncrement returns how far through a cycle pr moves each sample.
+func cycleIncrement(pr *PitchReader) float64 {
+	return pr.Frequency() / float64(pr.SampleRate)
+}
+
+// polyBLEP returns a correction for a jump of +2 at position 0 of a cycle, for a position t
+// within the cycle advancing dt each sample.
+func polyBLEP(t, dt float64) float64 {
+	switch {
+	case t < dt:
+		t /= dt
+		return t + t - t*t - 1
+	case t > 1-dt:
+		t = (t - 1) / dt
+		return t*t + t + t + 1
+	}
+	return 0
+}
+
+// polyBLAMP returns a correction for an increase in slope of 2 per sample at position 0 of a
+// cycle, for a position t within the cycle advancing dt each sample. It is the integral of polyBLEP.
+func polyBLAMP(t, dt float64) float64 {
+	switch {
+	case t < dt:
+		t = t/dt - 1
+		return -t * t * t / 3
+	case t > 1-dt:
+		t = (t-1)/dt + 1
+		return t * t * t / 3
+	}
+	return 0
 }